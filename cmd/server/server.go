@@ -1,76 +1,103 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"http-server/internal/data/database"
 	"http-server/internal/handlers"
-	"http-server/internal/models"
 	"http-server/internal/network"
-	"net"
+	"http-server/internal/server"
+	"log"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/joho/godotenv/autoload"
 )
 
 func main() {
 	port := flag.Int("port", 4221, "the port the server is hosted on")
+	idleTimeout := flag.Duration("idle-timeout", server.DEFAULT_IDLE_TIMEOUT, "how long a persistent connection may wait for its next request, or 0 for no limit")
+	readHeaderTimeout := flag.Duration("read-header-timeout", server.DEFAULT_READ_HEADER_TIMEOUT, "how long the request line and header fields may take to arrive, or 0 for no limit")
+	readBodyTimeout := flag.Duration("read-body-timeout", server.DEFAULT_READ_BODY_TIMEOUT, "how long a request body may take to arrive, or 0 for no limit")
+	writeTimeout := flag.Duration("write-timeout", server.DEFAULT_WRITE_TIMEOUT, "how long a request may take to be answered once it has been read, or 0 for no limit")
+	maxHeaderBytes := flag.Int("max-header-bytes", network.DefaultLimits.MaxHeaderBytes, "the maximum size of the request header fields in bytes")
+	maxHeaderCount := flag.Int("max-header-count", network.DefaultLimits.MaxHeaderCount, "the maximum number of request header fields")
+	maxBodyBytes := flag.Int64("max-body-bytes", network.DefaultLimits.MaxBodyBytes, "the maximum size of a request body in bytes")
+	maxConnections := flag.Int("max-connections", 1024, "the maximum number of connections served at the same time, or 0 for no limit")
+	queueSize := flag.Int("queue-size", 128, "how many connections may wait for a worker before new ones are answered with 503")
+	compressMinBytes := flag.Int("compress-min-bytes", handlers.DefaultCompressOptions.MinBytes, "smallest response body that is compressed with gzip or deflate")
+	gracePeriod := flag.Duration("grace-period", 10*time.Second, "how long active requests may take to finish when the server shuts down")
 	flag.Parse()
 
+	db, err := database.Open(os.Getenv("DB_URL"))
+	if err != nil {
+		fmt.Println("Failed to open database:", err)
+		os.Exit(1)
+	}
+
 	fmt.Println("Logs from program will appear below")
-	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", *port))
+	s, err := server.New(
+		server.WithAddress(fmt.Sprintf("0.0.0.0:%d", *port)),
+		server.WithDatabase(db),
+		server.WithIdleTimeout(*idleTimeout),
+		server.WithReadHeaderTimeout(*readHeaderTimeout),
+		server.WithReadBodyTimeout(*readBodyTimeout),
+		server.WithWriteTimeout(*writeTimeout),
+		server.WithMaxConnections(*maxConnections),
+		server.WithQueueSize(*queueSize),
+		server.WithLimits(network.Limits{
+			MaxHeaderBytes: *maxHeaderBytes,
+			MaxHeaderCount: *maxHeaderCount,
+			MaxBodyBytes:   *maxBodyBytes,
+		}),
+	)
 	if err != nil {
-		fmt.Println("Failed to bind to port", *port)
+		fmt.Println("Failed to start server:", err)
+		db.Close()
 		os.Exit(1)
 	}
 
-	defer listener.Close()
+	s.Router().Use(
+		handlers.Logger(log.Default()),
+		handlers.Compress(handlers.CompressOptions{MinBytes: *compressMinBytes}),
+	)
 
 	fmt.Println("Server is now listening on port", *port)
 
-	for {
-		// Accept incoming request
-		conn, err := listener.Accept()
-		if err != nil {
-			fmt.Println("Error accepting connection: ", err.Error())
-			os.Exit(1)
-		}
-
-		//Handle client in a goroutine
-		go handleConnection(conn)
-
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.Serve()
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		fmt.Println("Shutting down, waiting up to", *gracePeriod, "for active requests")
+	case err := <-serveErr:
+		fmt.Println("Error accepting connection: ", err.Error())
+		exitCode = 1
 	}
 
-}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *gracePeriod)
+	defer cancel()
 
-func handleConnection(conn net.Conn) {
-	defer conn.Close()
+	fmt.Println("Connections:", s.Stats())
 
-	if data := network.GetData(conn); len(data) > 0 {
-		http_request := extractParts(data)
-		handlers.RouteConnection(conn, http_request)
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		fmt.Println("Failed to shut down gracefully:", err)
+		exitCode = 1
 	}
-}
 
-func extractParts(value string) models.HttpRequest {
-	// Split the input value into headers and body
-	parts := strings.Split(value, "\r\n\r\n")
-	headersPart := parts[0]
-	var body string
-	if len(parts) > 1 {
-		body = parts[1]
+	if err := db.Close(); err != nil {
+		fmt.Println("Failed to close database:", err)
+		exitCode = 1
 	}
 
-	// Split headers part into request line and header lines
-	lines := strings.Split(headersPart, "\r\n")
-	status := lines[0]
-	headers := strings.Join(lines[1:], "\r\n")
-	method, path, version := extractHttpStatus(status)
-
-	return models.HttpRequest{Method: method, Path: path, Version: version, Headers: headers, Body: body}
-
-}
-
-// GET /echo/abc HTTP/1.1\r\n
-func extractHttpStatus(request string) (method, path, version string) {
-	slice := strings.Split(request, " ")
-	return slice[0], slice[1], slice[2]
+	os.Exit(exitCode)
 }
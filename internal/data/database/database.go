@@ -2,39 +2,47 @@ package database
 
 import (
 	"database/sql"
-	"log"
-	"os"
 
-	_ "github.com/joho/godotenv/autoload"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-var (
-	dburl                = os.Getenv("DB_URL")
-	db                   *sql.DB
-	dbRepositoryInstance *dbRepository
-)
-
-func init() {
-	var err error
+// MEMORY_URL opens a private in-memory database that disappears when it is
+// closed.
+const MEMORY_URL = ":memory:"
 
-	if dburl == "" {
-		dburl = ":memory:"
+// Open connects to the sqlite database at url and creates the tables the
+// repositories need. An empty url opens an in-memory database.
+func Open(url string) (DbRepository, error) {
+	if url == "" {
+		url = MEMORY_URL
 	}
-  
-	db, err = sql.Open("sqlite3", dburl)
+
+	db, err := sql.Open("sqlite3", url)
 	if err != nil {
 		// This will not be a connection error, but a DSN parse error or
 		// another initialization error.
-		log.Fatal(err)
+		return nil, err
+	}
+
+	if url == MEMORY_URL {
+		// Every connection to ":memory:" gets its own empty database, so the
+		// pool must never open a second one.
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := initTables(db); err != nil {
+		db.Close()
+		return nil, err
 	}
 
-	initTables()
+	return &dbRepository{db: db, url: url}, nil
 }
 
-func initTables() {
-	db.Exec(`CREATE TABLE IF NOT EXISTS user (
+func initTables(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS user (
             id INTEGER NOT NULL PRIMARY KEY ASC, 
             username TEXT NOT NULL UNIQUE, 
             password TEXT NOT NULL CHECK(length(password) > 5))`)
+
+	return err
 }
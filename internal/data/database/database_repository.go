@@ -29,19 +29,8 @@ type DbRepository interface {
 }
 
 type dbRepository struct {
-	db *sql.DB
-}
-
-func NewDbRepository() DbRepository {
-	if dbRepositoryInstance != nil {
-		return dbRepositoryInstance
-	}
-
-	dbRepositoryInstance = &dbRepository{
-		db: db,
-	}
-
-	return dbRepositoryInstance
+	db  *sql.DB
+	url string
 }
 
 func (r *dbRepository) Prepare(query string) (*sql.Stmt, error) {
@@ -104,7 +93,7 @@ func (r *dbRepository) Health() map[string]string {
 // If the connection is successfully closed, it returns nil.
 // If an error occurs while closing the connection, it returns the error.
 func (r *dbRepository) Close() error {
-	log.Printf("Disconnected from database: %s", dburl)
+	log.Printf("Disconnected from database: %s", r.url)
 	return r.db.Close()
 }
 
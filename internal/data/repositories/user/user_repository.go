@@ -1,6 +1,7 @@
 package userrepository
 
 import (
+	"context"
 	"fmt"
 	"http-server/internal/data/database"
 	"http-server/internal/models"
@@ -11,14 +12,20 @@ import (
 // Dao represents a data access object that interacts with the database.
 type UserRepository interface {
 	// GetUserById retrieves a user by its ID.
-	// It returns an error if the provided ID does not exist.
-	GetUserById(id int) (*models.User, error)
+	// It returns an error if the provided ID does not exist or ctx ends first.
+	GetUserById(ctx context.Context, id int) (*models.User, error)
 
 	// CreateUser stores a user with the specified username and password.
 	// It returns an error in two scenarios:
 	// 1. The username is already taken.
 	// 2. The password is shorter than 6 characters.
-	CreateUser(username, password string) error
+	// It also fails if ctx ends first.
+	CreateUser(ctx context.Context, username, password string) error
+
+	// UpdateUser changes the username and password of the user with the
+	// specified ID. Empty values leave the corresponding field unchanged.
+	// It returns the same errors as GetUserById and CreateUser.
+	UpdateUser(ctx context.Context, id int, username, password string) error
 
 	count() int
 
@@ -26,7 +33,8 @@ type UserRepository interface {
 }
 
 type userRepository struct {
-	db database.DbRepository
+	db         database.DbRepository
+	statements *userStatements
 }
 
 const (
@@ -36,20 +44,15 @@ const (
 	CREATE_USER_PASSWORD_TOO_SHORT_ERR = "Password must be 6 or more characters."
 )
 
-var (
-	userRepositoryInstance *userRepository
-)
-
-func NewUserRepository() UserRepository {
-	if userRepositoryInstance != nil {
-		return userRepositoryInstance
-	}
-
-	userRepositoryInstance = &userRepository{
-		db: database.NewDbRepository(),
+// NewUserRepository returns a UserRepository that stores users in db.
+// It returns an error if its statements cannot be prepared.
+func NewUserRepository(db database.DbRepository) (UserRepository, error) {
+	statements, err := prepareStatements(db)
+	if err != nil {
+		return nil, err
 	}
 
-	return userRepositoryInstance
+	return &userRepository{db: db, statements: statements}, nil
 }
 
 func (r *userRepository) count() int {
@@ -66,12 +69,12 @@ func (r *userRepository) deleteAll() error {
 	return r.db.DeleteAll(TABLE_NAME)
 }
 
-func (r *userRepository) GetUserById(id int) (*models.User, error) {
+func (r *userRepository) GetUserById(ctx context.Context, id int) (*models.User, error) {
 	var userId int
 	var username string
 	var password string
 
-	if err := getUserByIdStmt.QueryRow(id).Scan(&userId, &username, &password); err != nil {
+	if err := r.statements.getUserById.QueryRowContext(ctx, id).Scan(&userId, &username, &password); err != nil {
 		var msg string
 
 		if err.Error() == "sql: no rows in result set" {
@@ -86,8 +89,8 @@ func (r *userRepository) GetUserById(id int) (*models.User, error) {
 	return &models.User{Id: userId, Username: username, Password: password}, nil
 }
 
-func (r *userRepository) CreateUser(username, password string) error {
-	if _, err := createUserStmt.Exec(username, password); err != nil {
+func (r *userRepository) CreateUser(ctx context.Context, username, password string) error {
+	if _, err := r.statements.createUser.ExecContext(ctx, username, password); err != nil {
 		var msg string
 
 		switch {
@@ -104,3 +107,29 @@ func (r *userRepository) CreateUser(username, password string) error {
 
 	return nil
 }
+
+func (r *userRepository) UpdateUser(ctx context.Context, id int, username, password string) error {
+	result, err := r.statements.updateUser.ExecContext(ctx, username, password, id)
+	if err != nil {
+		var msg string
+
+		switch {
+		case strings.HasPrefix(err.Error(), "UNIQUE"):
+			msg = CREATE_USER_USERNAME_TAKEN_ERR
+		case strings.HasPrefix(err.Error(), "CHECK"):
+			msg = CREATE_USER_PASSWORD_TOO_SHORT_ERR
+		default:
+			msg = "UpdateUser unknown error: " + err.Error()
+		}
+
+		return fmt.Errorf(msg)
+	}
+
+	if updated, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("UpdateUser unknown error: " + err.Error())
+	} else if updated == 0 {
+		return fmt.Errorf(GET_USER_BY_ID_ERR)
+	}
+
+	return nil
+}
@@ -1,9 +1,12 @@
 package userrepository
 
 import (
+	"context"
 	"fmt"
+	"http-server/internal/data/database"
 	"http-server/internal/models"
 	testingutil "http-server/internal/util/testing"
+	"log"
 	"os"
 	"testing"
 )
@@ -126,7 +129,7 @@ func TestCreateUser(t *testing.T) {
 		const TEST_FUNCTION = "CreateUser"
 
 		executeSingleUserTest := func(t *testing.T, tt singleUserTest) int {
-			err := testFunction(tt.Username, tt.Password)
+			err := testFunction(context.Background(), tt.Username, tt.Password)
 			testingutil.ValidateError(t, TEST_FUNCTION, err, tt.Error)
 			return repository.count()
 		}
@@ -141,8 +144,8 @@ func TestCreateUser(t *testing.T) {
 		}
 
 		executeTwoUsersTest := func(t *testing.T, tt twoUsersTest) int {
-			testFunction(tt.users[0].Username, tt.users[0].Password)
-			err := testFunction(tt.users[1].Username, tt.users[1].Password)
+			testFunction(context.Background(), tt.users[0].Username, tt.users[0].Password)
+			err := testFunction(context.Background(), tt.users[1].Username, tt.users[1].Password)
 
 			testingutil.ValidateError(t, TEST_FUNCTION, err, tt.Error)
 
@@ -217,12 +220,12 @@ func TestGetUserById(t *testing.T) {
 		const TEST_FUNCTION = "GetUserById"
 
 		executeTests := func(t *testing.T, tt twoIdsTest) []models.User {
-			createUser(USER.Username, USER.Password)
-			createUser(ANOTHER_USER.Username, ANOTHER_USER.Password)
+			createUser(context.Background(), USER.Username, USER.Password)
+			createUser(context.Background(), ANOTHER_USER.Username, ANOTHER_USER.Password)
 			res := make([]models.User, 0, 2)
 
 			for _, id := range tt.ids {
-				user, err := testFunction(id)
+				user, err := testFunction(context.Background(), id)
 
 				testingutil.ValidateError(t, TEST_FUNCTION, err, tt.Error)
 
@@ -263,15 +266,108 @@ func TestGetUserById(t *testing.T) {
 	testingutil.HandleTests(t, tests, testHandler)
 }
 
+func TestUpdateUser(t *testing.T) {
+	testFunction := repository.UpdateUser
+	createUser := repository.CreateUser
+
+	setupTests := func() []singleUserTest {
+		tests := []singleUserTest{
+			{
+				testingutil.BasicTest{
+					Description: "Updates both fields",
+					Want:        models.User{Id: USER.Id, Username: "anna", Password: "654321"},
+				},
+				models.User{Id: USER.Id, Username: "anna", Password: "654321"},
+			},
+			{
+				testingutil.BasicTest{
+					Description: "Leaves empty fields unchanged",
+					Want:        models.User{Id: USER.Id, Username: "anna", Password: USER.Password},
+				},
+				models.User{Id: USER.Id, Username: "anna"},
+			},
+			{
+				testingutil.BasicTest{
+					Description: "Throws error if ID does not exist",
+					Want:        USER,
+					Error:       GET_USER_BY_ID_ERR,
+				},
+				models.User{Id: 3, Username: "anna"},
+			},
+			{
+				testingutil.BasicTest{
+					Description: "Conflicting usernames",
+					Want:        USER,
+					Error:       CREATE_USER_USERNAME_TAKEN_ERR,
+				},
+				models.User{Id: USER.Id, Username: ANOTHER_USER.Username},
+			},
+			{
+				testingutil.BasicTest{
+					Description: "Password too short (less than 6 chars)",
+					Want:        USER,
+					Error:       CREATE_USER_PASSWORD_TOO_SHORT_ERR,
+				},
+				models.User{Id: USER.Id, Password: "12345"},
+			},
+		}
+
+		return tests
+	}
+
+	setupTestHandler := func() testingutil.TestHandler {
+		const TEST_FUNCTION = "UpdateUser"
+
+		executeTests := func(t *testing.T, tt singleUserTest) models.User {
+			createUser(context.Background(), USER.Username, USER.Password)
+			createUser(context.Background(), ANOTHER_USER.Username, ANOTHER_USER.Password)
+
+			err := testFunction(context.Background(), tt.Id, tt.Username, tt.Password)
+			testingutil.ValidateError(t, TEST_FUNCTION, err, tt.Error)
+
+			user, err := repository.GetUserById(context.Background(), USER.Id)
+			if err != nil {
+				t.Fatalf("GetUserById(%d) returned '%s'", USER.Id, err)
+			}
+
+			return *user
+		}
+
+		validateTests := func(t *testing.T, tt singleUserTest, gotBeforeAssertion any) {
+			got, want := testingutil.AssertGotAndWantType[models.User](t, gotBeforeAssertion, tt.Want)
+			err := fmt.Sprintf(
+				"%s(%d, %s, %s) -> GetUserById(%d) = %s, want: %s",
+				TEST_FUNCTION, tt.Id, tt.Username, tt.Password, USER.Id, got.String(), want.String())
+
+			testingutil.ValidateResult(t, err, got, want)
+		}
+
+		return testingutil.GetTestHandler(executeTests, validateTests, cleanup)
+	}
+
+	tests := setupTests()
+	testHandler := setupTestHandler()
+
+	testingutil.HandleTests(t, tests, testHandler)
+}
+
 func cleanup() {
 	repository.deleteAll()
 }
 
 func beforeAll() func(int) {
-	repository = NewUserRepository()
+	db, err := database.Open(database.MEMORY_URL)
+	if err != nil {
+		log.Fatal("Could not open database: ", err)
+	}
+
+	if repository, err = NewUserRepository(db); err != nil {
+		log.Fatal("Could not create repository: ", err)
+	}
 
 	return func(code int) {
 		repository = nil
+		db.Close()
 		os.Exit(code)
 	}
 }
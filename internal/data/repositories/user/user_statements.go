@@ -2,42 +2,53 @@ package userrepository
 
 import (
 	"database/sql"
+	"fmt"
 	"http-server/internal/data/database"
-	"log"
 )
 
-var (
-	dbRepository    database.DbRepository
-	createUserStmt  *sql.Stmt
-	getUserByIdStmt *sql.Stmt
-)
-
-func init() {
-	dbRepository = database.NewDbRepository()
-	prepareStatements()
+type userStatements struct {
+	createUser  *sql.Stmt
+	getUserById *sql.Stmt
+	updateUser  *sql.Stmt
 }
 
-func prepareStatements() {
-	prepareCreateUserStmt()
-	prepareGetUserByIdStmt()
-}
+func prepareStatements(db database.DbRepository) (*userStatements, error) {
+	statements := &userStatements{}
+
+	for _, statement := range []struct {
+		name  string
+		query string
+		stmt  **sql.Stmt
+	}{
+		{"Create User", createUserQuery, &statements.createUser},
+		{"Get User By Id", getUserByIdQuery, &statements.getUserById},
+		{"Update User", updateUserQuery, &statements.updateUser},
+	} {
+		stmt, err := db.Prepare(statement.query)
+		if err != nil {
+			statements.close()
+			return nil, fmt.Errorf("could not prepare %s statement: %w", statement.name, err)
+		}
+
+		*statement.stmt = stmt
+	}
 
-func prepareCreateUserStmt() {
-	query := "INSERT INTO user (username, password) VALUES (?, ?)"
+	return statements, nil
+}
 
-	if stmt, err := dbRepository.Prepare(query); err != nil {
-		log.Fatal("Could not prepare Create User statement: ", err)
-	} else {
-		createUserStmt = stmt
+func (s *userStatements) close() {
+	for _, stmt := range []*sql.Stmt{s.createUser, s.getUserById, s.updateUser} {
+		if stmt != nil {
+			stmt.Close()
+		}
 	}
 }
 
-func prepareGetUserByIdStmt() {
-	query := "SELECT * FROM user WHERE ID = ?"
+const createUserQuery = "INSERT INTO user (username, password) VALUES (?, ?)"
 
-	if stmt, err := dbRepository.Prepare(query); err != nil {
-		log.Fatal("Could not prepare Get User By Id statement: ", err)
-	} else {
-		getUserByIdStmt = stmt
-	}
-}
+const getUserByIdQuery = "SELECT * FROM user WHERE ID = ?"
+
+const updateUserQuery = `UPDATE user SET
+            username = COALESCE(NULLIF(?, ''), username),
+            password = COALESCE(NULLIF(?, ''), password)
+            WHERE id = ?`
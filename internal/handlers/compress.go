@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ENCODING_GZIP    = "gzip"
+	ENCODING_DEFLATE = "deflate"
+)
+
+// CompressOptions configures the Compress middleware.
+// Zero fields fall back to the value in DefaultCompressOptions.
+type CompressOptions struct {
+	// MinBytes is the smallest body that is compressed. Smaller bodies are
+	// not worth the cost of compressing them.
+	MinBytes int
+
+	// Level is the compression level, from flate.HuffmanOnly to
+	// flate.BestCompression. Levels outside that range fall back to the
+	// default level.
+	Level int
+
+	// ContentTypes lists the media types that are compressed. An entry that
+	// ends in "/" matches every subtype, such as "text/".
+	ContentTypes []string
+}
+
+var DefaultCompressOptions = CompressOptions{
+	MinBytes: 1024,
+	Level:    flate.DefaultCompression,
+	ContentTypes: []string{
+		"text/",
+		"application/json",
+		"application/javascript",
+		"application/xml",
+		"image/svg+xml",
+	},
+}
+
+func (o CompressOptions) withDefaults() CompressOptions {
+	if o.MinBytes <= 0 {
+		o.MinBytes = DefaultCompressOptions.MinBytes
+	}
+	if o.Level == 0 || o.Level < flate.HuffmanOnly || o.Level > flate.BestCompression {
+		o.Level = DefaultCompressOptions.Level
+	}
+	if len(o.ContentTypes) == 0 {
+		o.ContentTypes = DefaultCompressOptions.ContentTypes
+	}
+	return o
+}
+
+// compressible reports whether a body with the Content-Type contentType is
+// compressed.
+func (o CompressOptions) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range o.ContentTypes {
+		if mediaType == t || strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compress returns a Middleware that compresses response bodies with gzip or
+// deflate, whichever the Accept-Encoding header of the request prefers.
+// Only bodies of the listed content types and of at least options.MinBytes
+// are compressed, and never responses that already have a Content-Encoding
+// or that answer a range request. Responses whose encoding depends on the
+// request get Vary: Accept-Encoding.
+func Compress(options CompressOptions) Middleware {
+	options = options.withDefaults()
+
+	return func(next HandlerFunction) HandlerFunction {
+		return func(w network.ResponseWriter, http models.HttpRequest) {
+			cw := &compressWriter{
+				ResponseWriter: w,
+				options:        options,
+				encoding:       negotiateEncoding(http.Headers.Values("Accept-Encoding")),
+			}
+			defer cw.close()
+
+			next(cw, http)
+		}
+	}
+}
+
+// compressWriter holds back the head and the start of the body of a
+// response until it can tell whether the body is compressed.
+type compressWriter struct {
+	network.ResponseWriter
+	options  CompressOptions
+	encoding string
+
+	status  int
+	buffer  []byte
+	written int64
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Status() int {
+	if w.status == 0 {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *compressWriter) Written() int64 {
+	return w.written
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(network.STATUS_OK)
+
+	if !w.decided {
+		if len(w.buffer)+len(data) < w.options.MinBytes {
+			w.buffer = append(w.buffer, data...)
+			w.written += int64(len(data))
+			return len(data), nil
+		}
+
+		w.decide(true)
+		if err := w.writeBuffer(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.body().Write(data)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *compressWriter) Flush() error {
+	if !w.decided {
+		// A body that is flushed before it reaches MinBytes may still grow,
+		// so it is compressed whatever its size.
+		w.decide(len(w.buffer) > 0)
+		if err := w.writeBuffer(); err != nil {
+			return err
+		}
+	}
+
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		if err := flusher.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return w.ResponseWriter.Flush()
+}
+
+// SetWriteDeadline passes the deadline on, so WriteTimeout keeps working
+// inside Compress.
+func (w *compressWriter) SetWriteDeadline(deadline time.Time) error {
+	conn, ok := w.ResponseWriter.(interface{ SetWriteDeadline(time.Time) error })
+	if !ok {
+		return network.ErrDeadlineNotSupported
+	}
+
+	return conn.SetWriteDeadline(deadline)
+}
+
+// decide sets up the compression of the response, if large says the body is
+// large enough and the response is eligible, and passes the head on.
+// The response is sent uncompressed if the encoder cannot be created.
+func (w *compressWriter) decide(large bool) {
+	w.decided = true
+	header := w.Header()
+
+	status := w.Status()
+	eligible := status != network.STATUS_PARTIAL_CONTENT &&
+		!header.Has("Content-Range") &&
+		!header.Has("Content-Encoding") &&
+		w.options.compressible(header.Get("Content-Type"))
+
+	if eligible {
+		header.Add("Vary", "Accept-Encoding")
+	}
+
+	if eligible && large && w.encoding != "" {
+		if encoder, err := w.newEncoder(); err == nil {
+			w.encoder = encoder
+			header.Set("Content-Encoding", w.encoding)
+			header.Del("Content-Length")
+		}
+	}
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+// newEncoder returns a writer that compresses into the response with the
+// negotiated encoding.
+func (w *compressWriter) newEncoder() (io.WriteCloser, error) {
+	if w.encoding == ENCODING_DEFLATE {
+		return zlib.NewWriterLevel(w.ResponseWriter, w.options.Level)
+	}
+	return gzip.NewWriterLevel(w.ResponseWriter, w.options.Level)
+}
+
+func (w *compressWriter) body() io.Writer {
+	if w.encoder != nil {
+		return w.encoder
+	}
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) writeBuffer() error {
+	buffer := w.buffer
+	w.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+
+	_, err := w.body().Write(buffer)
+	return err
+}
+
+// close sends what is still held back once the handler has returned, and
+// ends the compressed stream.
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decide(false)
+		w.writeBuffer()
+	}
+
+	if w.encoder != nil {
+		w.encoder.Close()
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from the values of an
+// Accept-Encoding header, by their q-values, preferring gzip on a tie.
+// It returns "" if the client accepts neither.
+func negotiateEncoding(values []string) string {
+	qvalues := map[string]float64{}
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			coding, params, _ := strings.Cut(element, ";")
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding == "" {
+				continue
+			}
+			if coding == "x-gzip" {
+				coding = ENCODING_GZIP
+			}
+
+			q := 1.0
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(name, "q") {
+					continue
+				}
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil || parsed < 0 || parsed > 1 {
+					parsed = 0
+				}
+				q = parsed
+			}
+			qvalues[coding] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, coding := range []string{ENCODING_GZIP, ENCODING_DEFLATE} {
+		q, ok := qvalues[coding]
+		if !ok {
+			q, ok = qvalues["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	testingutil "http-server/internal/util/testing"
+	"io"
+	"strings"
+	"testing"
+)
+
+type negotiateTest struct {
+	testingutil.BasicTest
+	acceptEncoding []string
+}
+
+func (test negotiateTest) String() string {
+	return test.Description
+}
+
+type compressTest struct {
+	testingutil.BasicTest
+	path           string
+	acceptEncoding string
+}
+
+func (test compressTest) String() string {
+	return test.Description
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	const TEST_FUNCTION = "negotiateEncoding"
+
+	tests := []negotiateTest{
+		{testingutil.BasicTest{Description: "No header", Want: ""}, nil},
+		{testingutil.BasicTest{Description: "gzip", Want: ENCODING_GZIP}, []string{"gzip"}},
+		{testingutil.BasicTest{Description: "gzip is preferred on a tie", Want: ENCODING_GZIP}, []string{"deflate, gzip"}},
+		{testingutil.BasicTest{Description: "Higher q-value wins", Want: ENCODING_DEFLATE}, []string{"gzip;q=0.5, deflate;q=0.8"}},
+		{testingutil.BasicTest{Description: "q=0 refuses a coding", Want: ENCODING_DEFLATE}, []string{"gzip;q=0", "deflate"}},
+		{testingutil.BasicTest{Description: "Wildcard", Want: ENCODING_GZIP}, []string{"br, *;q=0.1"}},
+		{testingutil.BasicTest{Description: "Wildcard does not override a listed coding", Want: ENCODING_DEFLATE}, []string{"gzip;q=0, *"}},
+		{testingutil.BasicTest{Description: "Only identity", Want: ""}, []string{"identity"}},
+		{testingutil.BasicTest{Description: "Case and spacing", Want: ENCODING_GZIP}, []string{" GZIP ; Q=1 "}},
+		{testingutil.BasicTest{Description: "Invalid q-value refuses a coding", Want: ""}, []string{"gzip;q=2"}},
+		{testingutil.BasicTest{Description: "x-gzip", Want: ENCODING_GZIP}, []string{"x-gzip"}},
+	}
+
+	executeTest := func(t *testing.T, tt negotiateTest) string {
+		return negotiateEncoding(tt.acceptEncoding)
+	}
+
+	validateTest := func(t *testing.T, tt negotiateTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%q) = %q, want: %q", TEST_FUNCTION, tt.acceptEncoding, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+// decodeResponse splits a response written by network.Response into the
+// header fields that Compress sets and its body, decoded with the
+// Content-Encoding of the response.
+func decodeResponse(raw string) string {
+	head, body, _ := strings.Cut(raw, "\r\n\r\n")
+
+	fields := []string{strings.SplitN(head, "\r\n", 2)[0]}
+	encoding := ""
+	chunked := false
+	for _, line := range strings.Split(head, "\r\n")[1:] {
+		name, value, _ := strings.Cut(line, ": ")
+		switch name {
+		case "Content-Encoding":
+			encoding = value
+			fields = append(fields, line)
+		case "Vary":
+			fields = append(fields, line)
+		case "Transfer-Encoding":
+			chunked = true
+		}
+	}
+
+	if chunked {
+		var decoded strings.Builder
+		for {
+			size, rest, _ := strings.Cut(body, "\r\n")
+			var n int
+			fmt.Sscanf(size, "%x", &n)
+			if n == 0 {
+				break
+			}
+			decoded.WriteString(rest[:n])
+			body = rest[n+len("\r\n"):]
+		}
+		body = decoded.String()
+	}
+
+	var reader io.Reader = strings.NewReader(body)
+	var err error
+	switch encoding {
+	case ENCODING_GZIP:
+		reader, err = gzip.NewReader(reader)
+	case ENCODING_DEFLATE:
+		reader, err = zlib.NewReader(reader)
+	}
+	if err != nil {
+		return err.Error()
+	}
+
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return err.Error()
+	}
+
+	return strings.Join(fields, ", ") + " | " + string(decoded)
+}
+
+func TestCompress(t *testing.T) {
+	const TEST_FUNCTION = "Compress"
+
+	large := strings.Repeat("a", 2048)
+	options := CompressOptions{MinBytes: 1024}
+
+	r := NewRouter()
+	r.Use(Compress(options))
+	r.Handle(GET, "/text", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_OK, large)
+	})
+	r.Handle(GET, "/small", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_OK, "small")
+	})
+	r.Handle(GET, "/json", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendJSON(w, network.STATUS_CREATED, map[string]string{"name": large})
+	})
+	r.Handle(GET, "/image", func(w network.ResponseWriter, _ models.HttpRequest) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(large))
+	})
+	r.Handle(GET, "/compressed", func(w network.ResponseWriter, _ models.HttpRequest) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Encoding", "br")
+		w.Write([]byte(large))
+	})
+	r.Handle(GET, "/range", func(w network.ResponseWriter, _ models.HttpRequest) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/4096", len(large)-1))
+		w.WriteHeader(network.STATUS_PARTIAL_CONTENT)
+		w.Write([]byte(large))
+	})
+	r.Handle(GET, "/stream", func(w network.ResponseWriter, _ models.HttpRequest) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("first "))
+		w.Flush()
+		w.Write([]byte("second"))
+	})
+
+	tests := []compressTest{
+		{
+			testingutil.BasicTest{Description: "Text is compressed with gzip", Want: "HTTP/1.1 200 OK, Content-Encoding: gzip, Vary: Accept-Encoding | " + large},
+			"/text", "gzip, deflate",
+		},
+		{
+			testingutil.BasicTest{Description: "Text is compressed with deflate", Want: "HTTP/1.1 200 OK, Content-Encoding: deflate, Vary: Accept-Encoding | " + large},
+			"/text", "deflate",
+		},
+		{
+			testingutil.BasicTest{Description: "Client without compression", Want: "HTTP/1.1 200 OK, Vary: Accept-Encoding | " + large},
+			"/text", "",
+		},
+		{
+			testingutil.BasicTest{Description: "Body below the threshold", Want: "HTTP/1.1 200 OK, Vary: Accept-Encoding | small"},
+			"/small", "gzip",
+		},
+		{
+			testingutil.BasicTest{Description: "JSON keeps its status", Want: "HTTP/1.1 201 Created, Content-Encoding: gzip, Vary: Accept-Encoding | {\"name\":\"" + large + "\"}"},
+			"/json", "gzip",
+		},
+		{
+			testingutil.BasicTest{Description: "Content type that is not compressed", Want: "HTTP/1.1 200 OK | " + large},
+			"/image", "gzip",
+		},
+		{
+			testingutil.BasicTest{Description: "Body that is already compressed", Want: "HTTP/1.1 200 OK, Content-Encoding: br | " + large},
+			"/compressed", "gzip",
+		},
+		{
+			testingutil.BasicTest{Description: "Range response", Want: "HTTP/1.1 206 Partial Content | " + large},
+			"/range", "gzip",
+		},
+		{
+			testingutil.BasicTest{Description: "Flushed body is compressed whatever its size", Want: "HTTP/1.1 200 OK, Content-Encoding: gzip, Vary: Accept-Encoding | first second"},
+			"/stream", "gzip",
+		},
+	}
+
+	executeTest := func(t *testing.T, tt compressTest) string {
+		request := models.HttpRequest{Method: GET, Path: tt.path, Version: "HTTP/1.1", Headers: models.Header{}}
+		if tt.acceptEncoding != "" {
+			request.Headers.Set("Accept-Encoding", tt.acceptEncoding)
+		}
+
+		var raw bytes.Buffer
+		response := network.NewResponse(&raw, request)
+		r.RouteConnection(response, request)
+		if err := response.Finish(); err != nil {
+			return err.Error()
+		}
+
+		return decodeResponse(raw.String())
+	}
+
+	validateTest := func(t *testing.T, tt compressTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%s, %q) = %.100q, want: %.100q", TEST_FUNCTION, tt.path, tt.acceptEncoding, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestCompressInvalidLevel(t *testing.T) {
+	body := strings.Repeat("a", 5000)
+
+	r := NewRouter()
+	r.Use(Compress(CompressOptions{Level: 42}))
+	r.Handle(GET, "/text", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_OK, body)
+	})
+
+	request := models.HttpRequest{Method: GET, Path: "/text", Version: "HTTP/1.1", Headers: models.Header{}}
+	request.Headers.Set("Accept-Encoding", ENCODING_GZIP)
+
+	var raw bytes.Buffer
+	response := network.NewResponse(&raw, request)
+	r.RouteConnection(response, request)
+	if err := response.Finish(); err != nil {
+		t.Fatalf("Response.Finish() returned error '%s'", err)
+	}
+
+	want := "HTTP/1.1 200 OK, Content-Encoding: gzip, Vary: Accept-Encoding | " + body
+	if got := decodeResponse(raw.String()); got != want {
+		t.Errorf("Compress(Level: 42) answered %.100q, want: %.100q", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+// Group registers routes on a Router that share a path prefix and middleware.
+// The group's middleware runs after the router's and before the route's own.
+type Group struct {
+	router     *Router
+	prefix     string
+	middleware []Middleware
+}
+
+// Group returns a Group whose routes run middleware.
+func (r *Router) Group(middleware ...Middleware) *Group {
+	return &Group{router: r, middleware: middleware}
+}
+
+// Prefix returns a Group whose routes are registered under prefix, such as
+// "/api/v1", and run middleware.
+func (r *Router) Prefix(prefix string, middleware ...Middleware) *Group {
+	return r.Group(middleware...).Prefix(prefix)
+}
+
+// Group returns a nested Group whose routes run the middleware of g and then
+// middleware.
+func (g *Group) Group(middleware ...Middleware) *Group {
+	return &Group{
+		router:     g.router,
+		prefix:     g.prefix,
+		middleware: append(append([]Middleware{}, g.middleware...), middleware...),
+	}
+}
+
+// Prefix returns a nested Group whose routes are registered under the prefix
+// of g followed by prefix, and run the middleware of g and then middleware.
+func (g *Group) Prefix(prefix string, middleware ...Middleware) *Group {
+	nested := g.Group(middleware...)
+	nested.prefix = g.prefix + prefix
+	return nested
+}
+
+// Use adds middleware to the routes registered on g from now on.
+func (g *Group) Use(middleware ...Middleware) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// Handle registers handler like Router.Handle under the group's prefix,
+// wrapped in the group's middleware and then in middleware.
+func (g *Group) Handle(method, pattern string, handler HandlerFunction, middleware ...Middleware) error {
+	if err := checkPrefix(g.prefix); err != nil {
+		return err
+	}
+
+	all := append(append([]Middleware{}, g.middleware...), middleware...)
+	return g.router.Handle(method, joinPattern(g.prefix, pattern), handler, all...)
+}
+
+// Mount registers every route of sub like Router.Mount under the group's
+// prefix, wrapped in the group's middleware and then in middleware.
+func (g *Group) Mount(prefix string, sub *Router, middleware ...Middleware) error {
+	if err := checkPrefix(prefix); err != nil {
+		return err
+	}
+
+	all := append(append([]Middleware{}, g.middleware...), middleware...)
+	return g.router.Mount(g.prefix+prefix, sub, all...)
+}
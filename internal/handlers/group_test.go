@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	testingutil "http-server/internal/util/testing"
+	"strings"
+	"testing"
+)
+
+func TestGroupsAndMount(t *testing.T) {
+	const TEST_FUNCTION = "RouteConnection"
+
+	trace := []string{}
+	handler := func(name string) HandlerFunction {
+		return func(w network.ResponseWriter, http models.HttpRequest) {
+			trace = append(trace, name+" "+http.PathVariables["id"])
+			network.SendText(w, network.STATUS_OK, name)
+		}
+	}
+
+	users := NewRouter()
+	users.Use(tracer("users", &trace))
+	users.Handle(GET, "/", handler("list"))
+	users.Handle(GET, "/{id:int}", handler("user"), tracer("route", &trace))
+
+	r := NewRouter()
+	api := r.Prefix("/api", tracer("api", &trace))
+	v1 := api.Prefix("/v1", tracer("v1", &trace))
+	v1.Handle(GET, "/status", handler("status"))
+	v1.Mount("/users", users, tracer("mount", &trace))
+	r.Mount("/legacy/users", users)
+
+	tests := []middlewareTest{
+		{
+			testingutil.BasicTest{Description: "Prefixed group", Want: "api, v1, status "},
+			"/api/v1/status",
+		},
+		{
+			testingutil.BasicTest{Description: "Mounted root", Want: "api, v1, mount, users, list "},
+			"/api/v1/users",
+		},
+		{
+			testingutil.BasicTest{Description: "Mounted route with a parameter", Want: "api, v1, mount, users, route, user 7"},
+			"/api/v1/users/7",
+		},
+		{
+			testingutil.BasicTest{Description: "Same router mounted twice", Want: "users, route, user 8"},
+			"/legacy/users/8",
+		},
+		{
+			testingutil.BasicTest{Description: "Prefix alone is not a route", Want: ""},
+			"/api/v1",
+		},
+		{
+			testingutil.BasicTest{Description: "Routes are not served without the prefix", Want: ""},
+			"/status",
+		},
+	}
+
+	executeTest := func(t *testing.T, tt middlewareTest) string {
+		trace = trace[:0]
+		w := &statusRecorder{header: models.Header{}}
+		r.RouteConnection(w, models.HttpRequest{Method: GET, Path: tt.request, Version: "HTTP/1.1"})
+
+		// Tracers also record what they saw on the way out; only the way in matters here.
+		in := []string{}
+		for _, step := range trace {
+			if !strings.HasPrefix(step, "/") {
+				in = append(in, step)
+			}
+		}
+		return strings.Join(in, ", ")
+	}
+
+	validateTest := func(t *testing.T, tt middlewareTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%s) ran %q, want: %q", TEST_FUNCTION, tt.request, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestMountErrors(t *testing.T) {
+	const TEST_FUNCTION = "Router.Mount"
+
+	tests := []routeConflictTest{
+		{
+			testingutil.BasicTest{Description: "Distinct prefixes", Want: ""},
+			[]string{"/a", "/b"},
+		},
+		{
+			testingutil.BasicTest{Description: "Same prefix twice", Want: ErrRouteConflict.Error()},
+			[]string{"/a", "/a"},
+		},
+		{
+			testingutil.BasicTest{Description: "Prefix with a trailing slash", Want: ErrInvalidPattern.Error()},
+			[]string{"/a/"},
+		},
+		{
+			testingutil.BasicTest{Description: "Relative prefix", Want: ErrInvalidPattern.Error()},
+			[]string{"a"},
+		},
+	}
+
+	sub := NewRouter()
+	sub.Handle(GET, "/{id:int}", nil)
+
+	executeTest := func(t *testing.T, tt routeConflictTest) string {
+		r := NewRouter()
+		for _, prefix := range tt.patterns {
+			if err := r.Mount(prefix, sub); err != nil {
+				for _, sentinel := range []error{ErrRouteConflict, ErrInvalidPattern} {
+					if errors.Is(err, sentinel) {
+						return sentinel.Error()
+					}
+				}
+				return err.Error()
+			}
+		}
+		return ""
+	}
+
+	validateTest := func(t *testing.T, tt routeConflictTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%v) failed with %q, want: %q", TEST_FUNCTION, tt.patterns, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
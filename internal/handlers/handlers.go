@@ -1,134 +1,202 @@
 package handlers
 
 import (
-	"fmt"
+	userrepository "http-server/internal/data/repositories/user"
 	"http-server/internal/models"
 	"http-server/internal/network"
-	"net"
+	"sort"
 	"strings"
 )
 
-type handlerFunction func(conn net.Conn, http models.HttpRequest)
+// HandlerFunction answers a request that was routed to it.
+type HandlerFunction func(w network.ResponseWriter, http models.HttpRequest)
 
-type handlerInfo struct {
+// route is a handler together with the method and pattern it is registered for.
+type route struct {
+	method  string
 	pattern string
-	handler handlerFunction
+	handler HandlerFunction
 }
 
-var (
-	getHandlers    = []handlerInfo{}
-	postHandlers   = []handlerInfo{}
-	putHandlers    = []handlerInfo{}
-	deleteHandlers = []handlerInfo{}
-)
-
 const (
-	GET    = "GET"
-	PUT    = "PUT"
-	POST   = "POST"
-	DELETE = "DELETE"
+	GET     = "GET"
+	HEAD    = "HEAD"
+	PUT     = "PUT"
+	POST    = "POST"
+	PATCH   = "PATCH"
+	DELETE  = "DELETE"
+	OPTIONS = "OPTIONS"
 )
 
-func init() {
-	registerHandlers()
+// methodOrder is the order the standard methods are listed in an Allow
+// header. Extension methods follow them in alphabetical order.
+var methodOrder = []string{GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS}
+
+// NewDefaultRouter returns a Router with the hello handler and the user
+// handlers mounted at "/users", which store users in users.
+func NewDefaultRouter(users userrepository.UserRepository) (*Router, error) {
+	router, err := newRouterWith(helloRoutes())
+	if err != nil {
+		return nil, err
+	}
+
+	userRouter, err := NewUserRouter(users)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := router.Mount("/users", userRouter); err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
+
+// newRouterWith returns a Router with routes registered.
+func newRouterWith(routes []route) (*Router, error) {
+	router := NewRouter()
+	for _, route := range routes {
+		if err := router.Handle(route.method, route.pattern, route.handler); err != nil {
+			return nil, err
+		}
+	}
+
+	return router, nil
+}
+
+func sendNotFoundPage(w network.ResponseWriter) {
+	network.SendHTML(w, network.STATUS_NOT_FOUND, "<html><body><h1>404 NOT FOUND</h1></body></html>")
+}
+
+func sendMethodNotAllowedPage(w network.ResponseWriter, allowed []string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	network.SendHTML(w, network.STATUS_METHOD_NOT_ALLOWED, "<html><body><h1>405 METHOD NOT ALLOWED</h1></body></html>")
 }
 
-func sendDefaultErrorPage(conn net.Conn) {
-	response := network.RESPONSE_METHOD_NOT_ALLOWED + network.CRLF + "<html><body><h1>405 METHOD NOT ALLOWED</h1></body></html>"
-	network.SendData(response, conn)
+// RouteConnection dispatches http to the handler registered for its method
+// and path, after running the router's middleware. HEAD requests without a
+// HEAD handler are served by the GET handler, with the body dropped by the
+// ResponseWriter, and OPTIONS requests are answered with the methods
+// registered for the path.
+func (r *Router) RouteConnection(w network.ResponseWriter, http models.HttpRequest) {
+	chain(r.dispatch, r.middleware)(w, http)
 }
 
-func RouteConnection(conn net.Conn, http models.HttpRequest) {
-	var handlers []handlerInfo
-
-	switch http.Method {
-	case GET:
-		handlers = getHandlers
-	case POST:
-		handlers = postHandlers
-	case PUT:
-		handlers = putHandlers
-	case DELETE:
-		handlers = deleteHandlers
-	default:
-		fmt.Println("Unsupported method:", http.Method)
-		sendDefaultErrorPage(conn)
+func (r *Router) dispatch(w network.ResponseWriter, http models.HttpRequest) {
+	path, query := splitPathAndQuery(http.Path)
+
+	if http.Method == OPTIONS && path == "*" {
+		r.sendOptions(w, nil)
 		return
 	}
 
-	path, query := splitPathAndQuery(http.Path)
-	for _, info := range handlers {
-		if pathVars, matched := matchAndExtract(info.pattern, path); matched {
-			queryParams := parseQueryParams(query)
-			http.Query = queryParams
-			http.PathVariables = pathVars
-			info.handler(conn, http)
-			return
-		}
+	segments, err := cleanPath(path)
+	if err != nil {
+		network.SendText(w, network.STATUS_BAD_REQUEST, err.Error())
+		return
 	}
 
-	sendDefaultErrorPage(conn)
-}
+	route, pathVars, redirect := r.find(segments)
+	if redirect != nil {
+		sendRedirect(w, http.Method, joinSegments(redirect), query)
+		return
+	}
+	if route == nil {
+		sendNotFoundPage(w)
+		return
+	}
 
-func matchAndExtract(pattern, path string) (map[string]string, bool) {
-	patternParts := strings.Split(pattern, "/")
-	pathParts := strings.Split(path, "/")
+	if http.Method == OPTIONS {
+		r.sendOptions(w, route)
+		return
+	}
 
-	if len(patternParts) != len(pathParts) {
-		return nil, false
+	handler, found := route.handlers[http.Method]
+	if !found && http.Method == HEAD {
+		handler, found = route.handlers[GET]
+	}
+	if !found {
+		sendMethodNotAllowedPage(w, r.allowedMethods(route))
+		return
 	}
 
-	vars := make(map[string]string)
-	for i := range patternParts {
-		if strings.HasPrefix(patternParts[i], "{") && strings.HasSuffix(patternParts[i], "}") {
-			key := patternParts[i][1 : len(patternParts[i])-1]
-			vars[key] = pathParts[i]
-		} else if patternParts[i] != pathParts[i] {
-			return nil, false
-		}
+	params, err := models.ParseQuery(query)
+	if err != nil {
+		network.SendText(w, network.STATUS_BAD_REQUEST, err.Error())
+		return
 	}
 
-	return vars, true
+	http.Path = joinSegments(segments)
+	if query != "" {
+		http.Path += "?" + query
+	}
+	http.Query = params
+	http.PathVariables = pathVars
+	handler(w, http)
 }
 
-func splitPathAndQuery(path string) (string, string) {
-	parts := strings.SplitN(path, "?", 2)
-	if len(parts) == 2 {
-		return parts[0], parts[1]
+// allowedMethods returns the methods that can be used on the path of route,
+// in the order they are listed in an Allow header. A nil route stands for
+// the whole server.
+func (r *Router) allowedMethods(route *node) []string {
+	allowed := map[string]bool{OPTIONS: true}
+	if route == nil {
+		for method := range r.methods {
+			allowed[method] = true
+		}
+	} else {
+		for method := range route.handlers {
+			allowed[method] = true
+		}
+	}
+
+	if allowed[GET] {
+		allowed[HEAD] = true
 	}
-	return path, ""
-}
 
-func parseQueryParams(query string) map[string]string {
-	params := make(map[string]string)
-	pairs := strings.Split(query, "&")
-	for _, pair := range pairs {
-		keyValue := strings.SplitN(pair, "=", 2)
-		if len(keyValue) == 2 {
-			params[keyValue[0]] = keyValue[1]
+	methods := []string{}
+	for _, method := range methodOrder {
+		if allowed[method] {
+			methods = append(methods, method)
+			delete(allowed, method)
 		}
 	}
-	return params
+
+	extensions := make([]string, 0, len(allowed))
+	for method := range allowed {
+		extensions = append(extensions, method)
+	}
+	sort.Strings(extensions)
+
+	return append(methods, extensions...)
 }
 
-func registerHandler(method string, pattern string, handler handlerFunction) {
-	info := handlerInfo{pattern: pattern, handler: handler}
-
-	switch method {
-	case GET:
-		getHandlers = append(getHandlers, info)
-	case POST:
-		postHandlers = append(postHandlers, info)
-	case PUT:
-		putHandlers = append(putHandlers, info)
-	case DELETE:
-		deleteHandlers = append(deleteHandlers, info)
-	default:
-		fmt.Println("Unsupported method:", method)
+func (r *Router) sendOptions(w network.ResponseWriter, route *node) {
+	w.Header().Set("Allow", strings.Join(r.allowedMethods(route), ", "))
+	w.WriteHeader(network.STATUS_NO_CONTENT)
+}
+
+// sendRedirect redirects a request to path, keeping its query. Requests
+// other than GET and HEAD are redirected with STATUS_PERMANENT_REDIRECT, so
+// clients repeat them with the same method and body.
+func sendRedirect(w network.ResponseWriter, method, path, query string) {
+	if query != "" {
+		path += "?" + query
+	}
+
+	status := network.STATUS_MOVED_PERMANENTLY
+	if method != GET && method != HEAD {
+		status = network.STATUS_PERMANENT_REDIRECT
 	}
+
+	w.Header().Set("Location", path)
+	w.WriteHeader(status)
 }
 
-func registerHandlers() {
-	registerHelloHandlers()
-	registerUserHandlers()
+func splitPathAndQuery(path string) (string, string) {
+	parts := strings.SplitN(path, "?", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return path, ""
 }
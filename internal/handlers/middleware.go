@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	"log"
+	"time"
+)
+
+// Middleware wraps a handler with logic that runs around it. A middleware
+// ends the request early by answering it without calling next, and it can
+// read the final status and size of the response from the ResponseWriter
+// once next has returned.
+type Middleware func(next HandlerFunction) HandlerFunction
+
+// chain wraps handler in middleware, so the first middleware runs first and
+// the handler runs last.
+func chain(handler HandlerFunction, middleware []Middleware) HandlerFunction {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// Logger returns a Middleware that logs the method, path, status, body size
+// and duration of every request to logger.
+func Logger(logger *log.Logger) Middleware {
+	return func(next HandlerFunction) HandlerFunction {
+		return func(w network.ResponseWriter, http models.HttpRequest) {
+			start := time.Now()
+			next(w, http)
+			logger.Printf("%s %s %d %dB %s", http.Method, http.Path, w.Status(), w.Written(), time.Since(start))
+		}
+	}
+}
+
+// Timeout returns a Middleware that cancels the context of the request once
+// timeout has passed. The handler is not interrupted, so it should stop work
+// that uses the context, such as database queries, and answer on its own.
+func Timeout(timeout time.Duration) Middleware {
+	return func(next HandlerFunction) HandlerFunction {
+		return func(w network.ResponseWriter, http models.HttpRequest) {
+			ctx, cancel := context.WithTimeout(http.Context(), timeout)
+			defer cancel()
+
+			next(w, http.WithContext(ctx))
+		}
+	}
+}
+
+// WriteTimeout returns a Middleware that gives the route timeout to answer a
+// request, measured from when the middleware runs, instead of the write
+// timeout of the server. A response that is not sent in time is cut off and
+// its connection closed.
+func WriteTimeout(timeout time.Duration) Middleware {
+	return func(next HandlerFunction) HandlerFunction {
+		return func(w network.ResponseWriter, http models.HttpRequest) {
+			if conn, ok := w.(interface{ SetWriteDeadline(time.Time) error }); ok {
+				conn.SetWriteDeadline(time.Now().Add(timeout))
+			}
+
+			next(w, http)
+		}
+	}
+}
+
+// ReadTimeout returns a Middleware that gives the route timeout, measured
+// from when the middleware runs, to receive the rest of the request body
+// instead of the read body timeout of the server. Reads of the body that pass
+// it fail with a *network.RequestError with STATUS_REQUEST_TIMEOUT.
+func ReadTimeout(timeout time.Duration) Middleware {
+	return func(next HandlerFunction) HandlerFunction {
+		return func(w network.ResponseWriter, http models.HttpRequest) {
+			if body, ok := http.Body.(interface{ SetReadDeadline(time.Time) error }); ok {
+				body.SetReadDeadline(time.Now().Add(timeout))
+			}
+
+			next(w, http)
+		}
+	}
+}
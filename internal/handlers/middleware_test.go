@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	testingutil "http-server/internal/util/testing"
+	"strings"
+	"testing"
+)
+
+type middlewareTest struct {
+	testingutil.BasicTest
+	request string
+}
+
+func (test middlewareTest) String() string {
+	return test.Description
+}
+
+// tracer returns a Middleware that appends name to trace before and after
+// calling the next handler, along with the status and size it observed.
+func tracer(name string, trace *[]string) Middleware {
+	return func(next HandlerFunction) HandlerFunction {
+		return func(w network.ResponseWriter, http models.HttpRequest) {
+			*trace = append(*trace, name)
+			next(w, http)
+			*trace = append(*trace, fmt.Sprintf("/%s %d %d", name, w.Status(), w.Written()))
+		}
+	}
+}
+
+// forbid is a Middleware that answers every request itself.
+func forbid(next HandlerFunction) HandlerFunction {
+	return func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_FORBIDDEN, "no")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	const TEST_FUNCTION = "RouteConnection"
+
+	trace := []string{}
+	handler := func(w network.ResponseWriter, _ models.HttpRequest) {
+		trace = append(trace, "handler")
+		network.SendText(w, network.STATUS_CREATED, "hello")
+	}
+
+	r := NewRouter()
+	r.Use(tracer("global", &trace))
+	r.Handle(GET, "/plain", handler)
+	r.Handle(GET, "/route", handler, tracer("route", &trace))
+
+	group := r.Group(tracer("group", &trace))
+	group.Handle(GET, "/group", handler, tracer("route", &trace))
+	group.Group(tracer("nested", &trace)).Handle(GET, "/nested", handler)
+	group.Handle(GET, "/forbidden", handler, forbid, tracer("route", &trace))
+
+	tests := []middlewareTest{
+		{
+			testingutil.BasicTest{
+				Description: "Global middleware",
+				Want:        "global, handler, /global 201 5",
+			},
+			"/plain",
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Route middleware runs inside global middleware",
+				Want:        "global, route, handler, /route 201 5, /global 201 5",
+			},
+			"/route",
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Group middleware runs between global and route middleware",
+				Want:        "global, group, route, handler, /route 201 5, /group 201 5, /global 201 5",
+			},
+			"/group",
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Nested groups",
+				Want:        "global, group, nested, handler, /nested 201 5, /group 201 5, /global 201 5",
+			},
+			"/nested",
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Middleware ends the request early",
+				Want:        "global, group, /group 403 2, /global 403 2",
+			},
+			"/forbidden",
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Global middleware sees unmatched requests",
+				Want:        "global, /global 404 48",
+			},
+			"/missing",
+		},
+	}
+
+	executeTest := func(t *testing.T, tt middlewareTest) string {
+		trace = trace[:0]
+		w := &statusRecorder{header: models.Header{}}
+		r.RouteConnection(w, models.HttpRequest{Method: GET, Path: tt.request, Version: "HTTP/1.1"})
+		return strings.Join(trace, ", ")
+	}
+
+	validateTest := func(t *testing.T, tt middlewareTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%s) ran %q, want: %q", TEST_FUNCTION, tt.request, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
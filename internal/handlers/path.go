@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidPath = errors.New("invalid request path")
+
+// TrailingSlash is how a Router treats a path that only matches a route once
+// a trailing slash is added or removed, such as "/users/" for "/users".
+type TrailingSlash int
+
+const (
+	// SLASH_STRICT answers such paths with STATUS_NOT_FOUND.
+	SLASH_STRICT TrailingSlash = iota
+	// SLASH_REDIRECT redirects such paths to the path of the route.
+	SLASH_REDIRECT
+	// SLASH_IGNORE serves such paths with the route.
+	SLASH_IGNORE
+)
+
+// cleanPath splits the path of a request into percent-decoded segments, as
+// they are matched against routes. Empty segments are dropped and dot
+// segments are removed as described in RFC 3986, decoded or not, so no
+// segment is "." or ".." and a path cannot climb above the root. A path that
+// ends with a slash, or with a dot segment, ends with an empty segment.
+// Segments that would decode to a "/" or a NUL byte, and invalid
+// percent-encodings, are rejected with ErrInvalidPath, since they could be
+// mistaken for separators by whatever the handler passes them to.
+// A path that does not start with "/" has no segments.
+func cleanPath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, nil
+	}
+
+	raw := strings.Split(path[1:], "/")
+	segments := make([]string, 0, len(raw))
+	trailingSlash := false
+
+	for _, rawSegment := range raw {
+		segment, err := url.PathUnescape(rawSegment)
+		if err != nil || strings.ContainsAny(segment, "/\x00") {
+			return nil, ErrInvalidPath
+		}
+
+		trailingSlash = false
+		switch segment {
+		case "", ".":
+			trailingSlash = true
+		case "..":
+			if len(segments) > 0 {
+				segments = segments[:len(segments)-1]
+			}
+			trailingSlash = true
+		default:
+			segments = append(segments, segment)
+		}
+	}
+
+	if trailingSlash || len(segments) == 0 {
+		segments = append(segments, "")
+	}
+
+	return segments, nil
+}
+
+// joinSegments is the inverse of cleanPath for segments it returned.
+func joinSegments(segments []string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	return "/" + strings.Join(escaped, "/")
+}
+
+// toggleSlash returns segments with the trailing slash removed if there is
+// one, or added otherwise. The root path and targets that are not paths, such
+// as the authority of a CONNECT request, have no alternative and return nil.
+func toggleSlash(segments []string) []string {
+	last := len(segments) - 1
+	switch {
+	case last < 0:
+		return nil
+	case last == 0 && segments[0] == "":
+		return nil
+	case segments[last] == "":
+		return segments[:last]
+	}
+	return append(append([]string{}, segments...), "")
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"fmt"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	testingutil "http-server/internal/util/testing"
+	"strings"
+	"testing"
+)
+
+type cleanPathTest struct {
+	testingutil.BasicTest
+	path string
+}
+
+func (test cleanPathTest) String() string {
+	return test.Description
+}
+
+func TestCleanPath(t *testing.T) {
+	const TEST_FUNCTION = "cleanPath"
+
+	tests := []cleanPathTest{
+		{testingutil.BasicTest{Description: "Root", Want: "[]"}, "/"},
+		{testingutil.BasicTest{Description: "Plain path", Want: "[users 1]"}, "/users/1"},
+		{testingutil.BasicTest{Description: "Trailing slash", Want: "[users ]"}, "/users/"},
+		{testingutil.BasicTest{Description: "Repeated slashes", Want: "[users 1]"}, "//users///1"},
+		{testingutil.BasicTest{Description: "Percent-encoding", Want: "[users a b+c]"}, "/users/a%20b+c"},
+		{testingutil.BasicTest{Description: "Dot segment", Want: "[users 1]"}, "/users/./1"},
+		{testingutil.BasicTest{Description: "Dot-dot segment", Want: "[hello]"}, "/users/../hello"},
+		{testingutil.BasicTest{Description: "Trailing dot-dot segment", Want: "[users ]"}, "/users/1/.."},
+		{testingutil.BasicTest{Description: "Encoded dot-dot segment", Want: "[hello]"}, "/users/%2e%2E/hello"},
+		{testingutil.BasicTest{Description: "Dot-dot above the root", Want: "[etc passwd]"}, "/../../etc/passwd"},
+		{testingutil.BasicTest{Description: "Dots inside a segment", Want: "[a..b ...]"}, "/a..b/..."},
+		{testingutil.BasicTest{Description: "Relative path", Want: "[]"}, "users"},
+		{testingutil.BasicTest{Description: "Invalid escape", Error: ErrInvalidPath.Error()}, "/users/%zz"},
+		{testingutil.BasicTest{Description: "Truncated escape", Error: ErrInvalidPath.Error()}, "/users/%2"},
+		{testingutil.BasicTest{Description: "Encoded slash", Error: ErrInvalidPath.Error()}, "/static/..%2f..%2fetc"},
+		{testingutil.BasicTest{Description: "Encoded NUL", Error: ErrInvalidPath.Error()}, "/static/a%00.txt"},
+	}
+
+	executeTest := func(t *testing.T, tt cleanPathTest) string {
+		segments, err := cleanPath(tt.path)
+		if tt.Error == "" && err != nil {
+			t.Errorf("%s(%q) returned '%s'", TEST_FUNCTION, tt.path, err)
+		} else if tt.Error != "" && err == nil {
+			t.Errorf("%s(%q) returned no error, want: '%s'", TEST_FUNCTION, tt.path, tt.Error)
+		}
+		testingutil.ValidateError(t, TEST_FUNCTION, err, tt.Error)
+
+		if err != nil {
+			return ""
+		}
+		// The root is a single empty segment, print it like an empty path.
+		if len(segments) == 1 && segments[0] == "" {
+			segments = nil
+		}
+		return fmt.Sprint(segments)
+	}
+
+	validateTest := func(t *testing.T, tt cleanPathTest, gotBeforeAssertion any) {
+		want, _ := tt.Want.(string)
+		got, _ := gotBeforeAssertion.(string)
+		err := fmt.Sprintf("%s(%q) = %s, want: %s", TEST_FUNCTION, tt.path, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+// TRAVERSAL_PATHS try to reach a file outside of the directory served by
+// "/static/{path...}".
+var TRAVERSAL_PATHS = []string{
+	"/static/../secret",
+	"/static/../../etc/passwd",
+	"/static/css/../../secret",
+	"/static/%2e%2e/secret",
+	"/static/%2E%2E/%2e%2e/etc/passwd",
+	"/static/.%2e/secret",
+	"/static/css/%2e%2e/%2e%2e/secret",
+	"/static/..%2fsecret",
+	"/static/..%2Fsecret",
+	"/static/%2e%2e%2fsecret",
+	"/static/..%5c..%5csecret",
+	"/static/%252e%252e/secret",
+	"/static//../secret",
+	"/static/./../secret",
+	"/static/css/..",
+	"/static/..",
+	"/static/%00/../secret",
+}
+
+func TestPathTraversal(t *testing.T) {
+	const TEST_FUNCTION = "RouteConnection"
+
+	for _, policy := range []TrailingSlash{SLASH_STRICT, SLASH_REDIRECT, SLASH_IGNORE} {
+		var served []string
+		router := NewRouter()
+		router.SetTrailingSlash(policy)
+		router.Handle(GET, "/static/{path...}", func(w network.ResponseWriter, http models.HttpRequest) {
+			served = append(served, http.PathVariables["path"])
+			network.SendText(w, network.STATUS_OK, "file")
+		})
+
+		for _, path := range TRAVERSAL_PATHS {
+			served = served[:0]
+			w := &statusRecorder{header: models.Header{}}
+			router.RouteConnection(w, models.HttpRequest{Method: GET, Path: path, Version: "HTTP/1.1"})
+
+			for _, file := range served {
+				for _, segment := range strings.Split(file, "/") {
+					if segment == ".." || segment == "." || strings.ContainsAny(segment, "\x00") {
+						t.Errorf("%s(%s) with policy %d served %q, which escapes the static directory", TEST_FUNCTION, path, policy, file)
+					}
+				}
+				if strings.HasPrefix(file, "/") {
+					t.Errorf("%s(%s) with policy %d served the absolute path %q", TEST_FUNCTION, path, policy, file)
+				}
+			}
+
+			if location := w.header.Get("Location"); location != "" && !strings.HasPrefix(location, "/static/") {
+				t.Errorf("%s(%s) with policy %d redirected to %q, outside of the static directory", TEST_FUNCTION, path, policy, location)
+			}
+		}
+	}
+}
+
+type trailingSlashTest struct {
+	testingutil.BasicTest
+	policy  TrailingSlash
+	request string
+}
+
+func (test trailingSlashTest) String() string {
+	return test.Description
+}
+
+func TestTrailingSlash(t *testing.T) {
+	const TEST_FUNCTION = "RouteConnection"
+
+	tests := []trailingSlashTest{
+		{testingutil.BasicTest{Description: "Strict without slash", Want: "200 /users"}, SLASH_STRICT, "GET /users"},
+		{testingutil.BasicTest{Description: "Strict with slash", Want: "404 "}, SLASH_STRICT, "GET /users/"},
+		{testingutil.BasicTest{Description: "Strict directory", Want: "404 "}, SLASH_STRICT, "GET /docs"},
+		{testingutil.BasicTest{Description: "Redirect adds slash", Want: "301 /docs/"}, SLASH_REDIRECT, "GET /docs"},
+		{testingutil.BasicTest{Description: "Redirect removes slash", Want: "301 /users?page=2"}, SLASH_REDIRECT, "GET /users/?page=2"},
+		{testingutil.BasicTest{Description: "Redirect of a cleaned path", Want: "301 /users"}, SLASH_REDIRECT, "GET //users/./"},
+		{testingutil.BasicTest{Description: "Redirect keeps the method", Want: "308 /users"}, SLASH_REDIRECT, "POST /users/"},
+		{testingutil.BasicTest{Description: "Redirect only to routes", Want: "404 "}, SLASH_REDIRECT, "GET /nope/"},
+		{testingutil.BasicTest{Description: "Exact match is not redirected", Want: "200 /docs/"}, SLASH_REDIRECT, "GET /docs/"},
+		{testingutil.BasicTest{Description: "Ignore with slash", Want: "200 /users/"}, SLASH_IGNORE, "GET /users/"},
+		{testingutil.BasicTest{Description: "Ignore without slash", Want: "200 /docs"}, SLASH_IGNORE, "GET /docs"},
+		{testingutil.BasicTest{Description: "Root is never redirected", Want: "404 "}, SLASH_REDIRECT, "GET /"},
+		{testingutil.BasicTest{Description: "Strict authority target", Want: "404 "}, SLASH_STRICT, "CONNECT example.com:443"},
+		{testingutil.BasicTest{Description: "Redirect authority target", Want: "404 "}, SLASH_REDIRECT, "CONNECT example.com:443"},
+		{testingutil.BasicTest{Description: "Ignore authority target", Want: "404 "}, SLASH_IGNORE, "CONNECT example.com:443"},
+		{testingutil.BasicTest{Description: "Redirect relative target", Want: "404 "}, SLASH_REDIRECT, "GET users"},
+		{testingutil.BasicTest{Description: "Ignore relative target", Want: "404 "}, SLASH_IGNORE, "GET users"},
+	}
+
+	executeTest := func(t *testing.T, tt trailingSlashTest) string {
+		served := ""
+		handler := func(w network.ResponseWriter, http models.HttpRequest) {
+			served = http.Path
+		}
+
+		router := NewRouter()
+		router.SetTrailingSlash(tt.policy)
+		router.Handle(GET, "/users", handler)
+		router.Handle(POST, "/users", handler)
+		router.Handle(GET, "/docs/", handler)
+
+		method, path, _ := strings.Cut(tt.request, " ")
+		w := &statusRecorder{header: models.Header{}}
+		router.RouteConnection(w, models.HttpRequest{Method: method, Path: path, Version: "HTTP/1.1"})
+
+		if location := w.header.Get("Location"); location != "" {
+			served = location
+		}
+		return fmt.Sprintf("%d %s", w.Status(), served)
+	}
+
+	validateTest := func(t *testing.T, tt trailingSlashTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%s) with policy %d = %q, want: %q", TEST_FUNCTION, tt.request, tt.policy, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
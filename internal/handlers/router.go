@@ -0,0 +1,438 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"http-server/internal/network"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidMethod  = errors.New("invalid method")
+	ErrInvalidPattern = errors.New("invalid route pattern")
+	ErrRouteConflict  = errors.New("conflicting route")
+)
+
+// Parameter kinds, in the order they are tried when several parameters can
+// match the same segment.
+const (
+	paramInt = iota
+	paramRegexp
+	paramAny
+)
+
+// Router finds the handlers for a path in a trie with one level per path
+// segment. Patterns are made of static segments and parameters:
+//
+//	/users/me            static segments
+//	/users/{id}          any single segment
+//	/users/{id:int}      a segment of decimal digits
+//	/posts/{slug:[a-z-]+} a segment matching the regular expression
+//	/static/{path...}    the rest of the path, which must be the last segment
+//
+// When several routes match a path, static segments win over parameters,
+// typed parameters win over untyped ones, and catch-alls are tried last.
+// Parameters with regular expressions are tried in the order they were
+// registered.
+//
+// Paths are matched once they have been cleaned: percent-encodings are
+// decoded, repeated slashes are collapsed and dot segments are removed, so
+// "/users/%31", "/users//1" and "/static/../users/1" all match
+// "/users/{id:int}" with id "1". A trailing slash is handled as set with
+// SetTrailingSlash.
+type Router struct {
+	root          *node
+	methods       map[string]bool
+	middleware    []Middleware
+	routes        []route
+	trailingSlash TrailingSlash
+}
+
+type node struct {
+	static   map[string]*node
+	params   []*param
+	catchAll *param
+
+	pattern  string
+	handlers map[string]HandlerFunction
+}
+
+// param is an edge of the trie that matches a segment by its constraint
+// rather than by its value.
+type param struct {
+	name       string
+	kind       int
+	constraint string
+	regexp     *regexp.Regexp
+	child      *node
+}
+
+// NewRouter returns a Router without any routes.
+func NewRouter() *Router {
+	return &Router{root: newNode(), methods: map[string]bool{}}
+}
+
+func newNode() *node {
+	return &node{static: map[string]*node{}, handlers: map[string]HandlerFunction{}}
+}
+
+// Handle registers handler for requests with method whose path matches
+// pattern, wrapped in middleware. Any method token can be registered,
+// including extension methods such as PURGE or REPORT, but OPTIONS is always
+// answered by the router itself. It fails if the pattern is malformed, or if
+// the route could be confused with one that is already registered.
+func (r *Router) Handle(method, pattern string, handler HandlerFunction, middleware ...Middleware) error {
+	if !network.IsMethod(method) || method == OPTIONS {
+		return fmt.Errorf("%w %q for %s", ErrInvalidMethod, method, pattern)
+	}
+
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("%w %q: must start with /", ErrInvalidPattern, pattern)
+	}
+
+	segments, err := parsePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	// The trie is only changed once the route is known to fit in it, so a
+	// route that is rejected leaves no nodes behind.
+	if existing, err := r.root.walk(segments, pattern, false); err != nil {
+		return err
+	} else if existing != nil {
+		if _, found := existing.handlers[method]; found {
+			return conflict(pattern, existing.pattern)
+		}
+	}
+	current, _ := r.root.walk(segments, pattern, true)
+
+	handler = chain(handler, middleware)
+	current.pattern = pattern
+	current.handlers[method] = handler
+	r.methods[method] = true
+	r.routes = append(r.routes, route{method: method, pattern: pattern, handler: handler})
+	return nil
+}
+
+// Mount registers every route of sub under prefix, so "/users/{id}" mounted
+// at "/api" is served at "/api/users/{id}". The mounted routes run the
+// middleware given here, then the middleware of sub and then their own.
+// Routes and middleware added to sub after it is mounted are not copied.
+func (r *Router) Mount(prefix string, sub *Router, middleware ...Middleware) error {
+	if err := checkPrefix(prefix); err != nil {
+		return err
+	}
+
+	all := append(append([]Middleware{}, middleware...), sub.middleware...)
+	for _, route := range sub.routes {
+		if err := r.Handle(route.method, joinPattern(prefix, route.pattern), route.handler, all...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkPrefix accepts "" and paths such as "/api/v1" without a trailing slash.
+func checkPrefix(prefix string) error {
+	if prefix != "" && (!strings.HasPrefix(prefix, "/") || strings.HasSuffix(prefix, "/")) {
+		return fmt.Errorf("%w %q: prefixes must start and not end with /", ErrInvalidPattern, prefix)
+	}
+	return nil
+}
+
+// joinPattern puts prefix in front of pattern. The root pattern "/" of a
+// prefixed router is the prefix itself.
+func joinPattern(prefix, pattern string) string {
+	if prefix != "" && pattern == "/" {
+		return prefix
+	}
+	return prefix + pattern
+}
+
+// patternSegment is a segment of a pattern, either a static value or a
+// parameter.
+type patternSegment struct {
+	static string
+	param  *param
+	// catchAll marks a parameter that matches the rest of the path.
+	catchAll bool
+}
+
+// parsePattern splits pattern into its segments and checks that they are
+// well-formed, without looking at the routes that are already registered.
+func parsePattern(pattern string) ([]patternSegment, error) {
+	segments := strings.Split(pattern[1:], "/")
+	parsed := make([]patternSegment, 0, len(segments))
+	names := map[string]bool{}
+
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			if strings.ContainsAny(segment, "{}") {
+				return nil, fmt.Errorf("%w %q: braces must enclose a whole segment", ErrInvalidPattern, pattern)
+			}
+			// Cleaned paths never contain these, so the route could not match.
+			if segment == "." || segment == ".." || (segment == "" && i != len(segments)-1) {
+				return nil, fmt.Errorf("%w %q: empty and dot segments never match", ErrInvalidPattern, pattern)
+			}
+
+			parsed = append(parsed, patternSegment{static: segment})
+			continue
+		}
+
+		p, err := parseParam(segment)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %s", ErrInvalidPattern, pattern, err)
+		}
+
+		catchAll := p.kind == paramAny && strings.HasSuffix(p.name, "...")
+		if catchAll {
+			if i != len(segments)-1 {
+				return nil, fmt.Errorf("%w %q: catch-all must be the last segment", ErrInvalidPattern, pattern)
+			}
+			p.name = strings.TrimSuffix(p.name, "...")
+		}
+
+		if names[p.name] {
+			return nil, fmt.Errorf("%w %q: parameter %q is used twice", ErrInvalidPattern, pattern, p.name)
+		}
+		names[p.name] = true
+
+		parsed = append(parsed, patternSegment{param: p, catchAll: catchAll})
+	}
+
+	return parsed, nil
+}
+
+// walk follows segments down from n and returns the node they lead to.
+// Without create, it stops and returns nil where the trie ends, so it only
+// reports conflicts with existing routes. With create, the missing nodes and
+// edges are added; it cannot fail once walk without create has succeeded.
+func (n *node) walk(segments []patternSegment, pattern string, create bool) (*node, error) {
+	current := n
+	for _, segment := range segments {
+		switch {
+		case segment.param == nil:
+			child, found := current.static[segment.static]
+			if !found {
+				if !create {
+					return nil, nil
+				}
+				child = newNode()
+				current.static[segment.static] = child
+			}
+			current = child
+
+		case segment.catchAll:
+			if current.catchAll == nil {
+				if !create {
+					return nil, nil
+				}
+				current.catchAll = &param{name: segment.param.name, kind: paramAny, child: newNode()}
+			} else if current.catchAll.name != segment.param.name {
+				return nil, conflict(pattern, current.catchAll.child.pattern)
+			}
+			current = current.catchAll.child
+
+		default:
+			existing, err := current.findParam(segment.param, pattern)
+			if err != nil {
+				return nil, err
+			}
+			if existing == nil {
+				if !create {
+					return nil, nil
+				}
+				existing = current.addParam(segment.param)
+			}
+			current = existing.child
+		}
+	}
+
+	return current, nil
+}
+
+// findParam returns the edge of n with the same constraint as p, if any. Two
+// parameters with the same constraint but different names at the same
+// position would be ambiguous, so they conflict.
+func (n *node) findParam(p *param, pattern string) (*param, error) {
+	for _, existing := range n.params {
+		if existing.kind != p.kind || existing.constraint != p.constraint {
+			continue
+		}
+		if existing.name != p.name {
+			return nil, conflict(pattern, firstPattern(existing.child))
+		}
+		return existing, nil
+	}
+	return nil, nil
+}
+
+// addParam adds a new edge to n for a copy of p and returns it.
+func (n *node) addParam(p *param) *param {
+	edge := &param{name: p.name, kind: p.kind, constraint: p.constraint, regexp: p.regexp, child: newNode()}
+
+	// Keep the edges sorted by kind, and by registration order within a kind.
+	i := len(n.params)
+	for i > 0 && n.params[i-1].kind > edge.kind {
+		i--
+	}
+	n.params = append(n.params[:i], append([]*param{edge}, n.params[i:]...)...)
+
+	return edge
+}
+
+// parseParam parses a parameter segment such as "{id}", "{id:int}",
+// "{slug:[a-z-]+}" or "{path...}".
+func parseParam(segment string) (*param, error) {
+	if !strings.HasSuffix(segment, "}") {
+		return nil, errors.New("unterminated parameter")
+	}
+
+	name, constraint, _ := strings.Cut(segment[1:len(segment)-1], ":")
+	if strings.TrimSuffix(name, "...") == "" {
+		return nil, errors.New("parameter without a name")
+	}
+
+	switch {
+	case constraint == "":
+		return &param{name: name, kind: paramAny}, nil
+	case strings.HasSuffix(name, "..."):
+		return nil, errors.New("catch-all parameters cannot have a constraint")
+	case constraint == "int":
+		return &param{name: name, kind: paramInt, constraint: constraint}, nil
+	}
+
+	re, err := regexp.Compile("^(?:" + constraint + ")$")
+	if err != nil {
+		return nil, err
+	}
+
+	return &param{name: name, kind: paramRegexp, constraint: constraint, regexp: re}, nil
+}
+
+// Use adds middleware that runs around every request the router answers,
+// including the ones that no route matches. Path variables and the query are
+// not parsed yet when it runs. Use must not be called while the router is
+// serving requests.
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// SetTrailingSlash sets how paths that only match a route once a trailing
+// slash is added or removed are answered. The default is SLASH_STRICT.
+// SetTrailingSlash must not be called while the router is serving requests.
+func (r *Router) SetTrailingSlash(policy TrailingSlash) {
+	r.trailingSlash = policy
+}
+
+// match returns the node of the most specific route matching the segments of
+// a cleaned path, along with the values of its parameters, or nil if no route
+// matches.
+func (r *Router) match(segments []string) (*node, map[string]string) {
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	vars := map[string]string{}
+	if n := r.root.match(segments, vars); n != nil {
+		return n, vars
+	}
+
+	return nil, nil
+}
+
+// find is match with the trailing slash policy applied. If the path should be
+// redirected, it returns no node and the segments to redirect to.
+func (r *Router) find(segments []string) (*node, map[string]string, []string) {
+	if n, vars := r.match(segments); n != nil || r.trailingSlash == SLASH_STRICT {
+		return n, vars, nil
+	}
+
+	alternative := toggleSlash(segments)
+	n, vars := r.match(alternative)
+	if n == nil || r.trailingSlash == SLASH_IGNORE {
+		return n, vars, nil
+	}
+
+	return nil, nil, alternative
+}
+
+func (n *node) match(segments []string, vars map[string]string) *node {
+	if len(segments) == 0 {
+		if len(n.handlers) == 0 {
+			return nil
+		}
+		return n
+	}
+
+	segment, rest := segments[0], segments[1:]
+
+	if child, found := n.static[segment]; found {
+		if match := child.match(rest, vars); match != nil {
+			return match
+		}
+	}
+
+	for _, p := range n.params {
+		if !p.matches(segment) {
+			continue
+		}
+
+		if match := p.child.match(rest, vars); match != nil {
+			vars[p.name] = segment
+			return match
+		}
+	}
+
+	if n.catchAll != nil && len(n.catchAll.child.handlers) > 0 {
+		vars[n.catchAll.name] = strings.Join(segments, "/")
+		return n.catchAll.child
+	}
+
+	return nil
+}
+
+func (p *param) matches(segment string) bool {
+	switch p.kind {
+	case paramInt:
+		_, err := strconv.ParseUint(segment, 10, 64)
+		return err == nil
+	case paramRegexp:
+		return p.regexp.MatchString(segment)
+	default:
+		return segment != ""
+	}
+}
+
+// firstPattern returns a pattern registered at or below n, for error messages.
+func firstPattern(n *node) string {
+	if n.pattern != "" {
+		return n.pattern
+	}
+
+	for _, child := range n.static {
+		if pattern := firstPattern(child); pattern != "" {
+			return pattern
+		}
+	}
+	for _, p := range n.params {
+		if pattern := firstPattern(p.child); pattern != "" {
+			return pattern
+		}
+	}
+	if n.catchAll != nil {
+		return firstPattern(n.catchAll.child)
+	}
+
+	return ""
+}
+
+func conflict(pattern, existing string) error {
+	if existing == "" {
+		return fmt.Errorf("%w %q", ErrRouteConflict, pattern)
+	}
+	return fmt.Errorf("%w %q: conflicts with %q", ErrRouteConflict, pattern, existing)
+}
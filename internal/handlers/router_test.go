@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"http-server/internal/data/database"
+	userrepository "http-server/internal/data/repositories/user"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	testingutil "http-server/internal/util/testing"
+	"sort"
+	"strings"
+	"testing"
+)
+
+type routeMatchTest struct {
+	testingutil.BasicTest
+	path string
+}
+
+type routeConflictTest struct {
+	testingutil.BasicTest
+	patterns []string
+}
+
+func (test routeMatchTest) String() string {
+	return test.Description
+}
+
+func (test routeConflictTest) String() string {
+	return test.Description
+}
+
+// TEST_ROUTES are registered for GET in TestRouterMatch.
+var TEST_ROUTES = []string{
+	"/",
+	"/users",
+	"/users/me",
+	"/users/{id:int}",
+	"/users/{name}",
+	"/users/{id:int}/posts",
+	"/posts/{slug:[a-z-]+}",
+	"/posts/{id}/edit",
+	"/static/{path...}",
+	"/static/index.html",
+}
+
+func TestRouterMatch(t *testing.T) {
+	const TEST_FUNCTION = "Router.match"
+
+	r := NewRouter()
+	for _, pattern := range TEST_ROUTES {
+		if err := r.Handle(GET, pattern, nil); err != nil {
+			t.Fatalf("Router.Handle(%s, %s) returned '%s'", GET, pattern, err)
+		}
+	}
+
+	tests := []routeMatchTest{
+		{testingutil.BasicTest{Description: "Root", Want: "/"}, "/"},
+		{testingutil.BasicTest{Description: "Static route", Want: "/users"}, "/users"},
+		{testingutil.BasicTest{Description: "Static beats parameters", Want: "/users/me"}, "/users/me"},
+		{testingutil.BasicTest{Description: "Typed parameter beats untyped", Want: "/users/{id:int} id=42"}, "/users/42"},
+		{testingutil.BasicTest{Description: "Untyped parameter", Want: "/users/{name} name=abc"}, "/users/abc"},
+		{testingutil.BasicTest{Description: "Nested typed parameter", Want: "/users/{id:int}/posts id=7"}, "/users/7/posts"},
+		{testingutil.BasicTest{Description: "Int constraint rejects letters", Want: ""}, "/users/abc/posts"},
+		{testingutil.BasicTest{Description: "Regular expression constraint", Want: "/posts/{slug:[a-z-]+} slug=hello-world"}, "/posts/hello-world"},
+		{testingutil.BasicTest{Description: "Regular expression constraint is anchored", Want: ""}, "/posts/Hello"},
+		{testingutil.BasicTest{Description: "Backtracks to another parameter", Want: "/posts/{id}/edit id=hello"}, "/posts/hello/edit"},
+		{testingutil.BasicTest{Description: "Static beats catch-all", Want: "/static/index.html"}, "/static/index.html"},
+		{testingutil.BasicTest{Description: "Catch-all", Want: "/static/{path...} path=css/site.css"}, "/static/css/site.css"},
+		{testingutil.BasicTest{Description: "Catch-all of an empty path", Want: "/static/{path...} path="}, "/static/"},
+		{testingutil.BasicTest{Description: "Catch-all needs a segment", Want: ""}, "/static"},
+		{testingutil.BasicTest{Description: "Repeated slashes are collapsed", Want: "/users/{id:int}/posts id=7"}, "/users//7//posts"},
+		{testingutil.BasicTest{Description: "Percent-encoded segment", Want: "/users/{id:int} id=1"}, "/users/%31"},
+		{testingutil.BasicTest{Description: "Path variables are decoded", Want: "/users/{name} name=a b"}, "/users/a%20b"},
+		{testingutil.BasicTest{Description: "Dot segments are removed", Want: "/users/{id:int} id=1"}, "/static/../users/./1"},
+		{testingutil.BasicTest{Description: "Trailing slash", Want: ""}, "/users/"},
+		{testingutil.BasicTest{Description: "Unknown path", Want: ""}, "/unknown"},
+	}
+
+	executeTest := func(t *testing.T, tt routeMatchTest) string {
+		segments, _ := cleanPath(tt.path)
+		route, vars := r.match(segments)
+		if route == nil {
+			return ""
+		}
+
+		parts := []string{route.pattern}
+		for name, value := range vars {
+			parts = append(parts, name+"="+value)
+		}
+		sort.Strings(parts[1:])
+
+		return strings.Join(parts, " ")
+	}
+
+	validateTest := func(t *testing.T, tt routeMatchTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%s) = %q, want: %q", TEST_FUNCTION, tt.path, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestRouterHandle(t *testing.T) {
+	const TEST_FUNCTION = "Router.Handle"
+
+	tests := []routeConflictTest{
+		{
+			testingutil.BasicTest{Description: "Distinct routes", Want: ""},
+			[]string{"/users/{id:int}", "/users/{name}", "/users/{slug:[a-z]+}", "/users/{rest...}"},
+		},
+		{
+			testingutil.BasicTest{Description: "Same route twice", Want: ErrRouteConflict.Error()},
+			[]string{"/users", "/users"},
+		},
+		{
+			testingutil.BasicTest{Description: "Same route with different names", Want: ErrRouteConflict.Error()},
+			[]string{"/users/{id}", "/users/{name}"},
+		},
+		{
+			testingutil.BasicTest{Description: "Same constraint with different names", Want: ErrRouteConflict.Error()},
+			[]string{"/users/{id:int}/posts", "/users/{uid:int}"},
+		},
+		{
+			testingutil.BasicTest{Description: "Catch-alls with different names", Want: ErrRouteConflict.Error()},
+			[]string{"/static/{path...}", "/static/{file...}"},
+		},
+		{
+			testingutil.BasicTest{Description: "Catch-all before the last segment", Want: ErrInvalidPattern.Error()},
+			[]string{"/static/{path...}/edit"},
+		},
+		{
+			testingutil.BasicTest{Description: "Invalid regular expression", Want: ErrInvalidPattern.Error()},
+			[]string{"/posts/{slug:[a-z}"},
+		},
+		{
+			testingutil.BasicTest{Description: "Parameter inside a segment", Want: ErrInvalidPattern.Error()},
+			[]string{"/posts/post-{id}"},
+		},
+		{
+			testingutil.BasicTest{Description: "Parameter used twice", Want: ErrInvalidPattern.Error()},
+			[]string{"/users/{id}/friends/{id}"},
+		},
+		{
+			testingutil.BasicTest{Description: "Relative pattern", Want: ErrInvalidPattern.Error()},
+			[]string{"users"},
+		},
+		{
+			testingutil.BasicTest{Description: "Empty segment", Want: ErrInvalidPattern.Error()},
+			[]string{"/users//posts"},
+		},
+		{
+			testingutil.BasicTest{Description: "Dot segment", Want: ErrInvalidPattern.Error()},
+			[]string{"/static/../users"},
+		},
+		{
+			testingutil.BasicTest{Description: "Same route with other methods", Want: ""},
+			[]string{"GET /users", "PURGE /users", "PATCH /users"},
+		},
+		{
+			testingutil.BasicTest{Description: "Invalid method", Want: ErrInvalidMethod.Error()},
+			[]string{"GE(T /users"},
+		},
+		{
+			testingutil.BasicTest{Description: "OPTIONS is answered by the router", Want: ErrInvalidMethod.Error()},
+			[]string{"OPTIONS /users"},
+		},
+	}
+
+	executeTest := func(t *testing.T, tt routeConflictTest) string {
+		r := NewRouter()
+		for _, pattern := range tt.patterns {
+			method, pattern, found := strings.Cut(pattern, " ")
+			if !found {
+				method, pattern = GET, method
+			}
+
+			if err := r.Handle(method, pattern, nil); err != nil {
+				for _, sentinel := range []error{ErrRouteConflict, ErrInvalidPattern, ErrInvalidMethod} {
+					if errors.Is(err, sentinel) {
+						return sentinel.Error()
+					}
+				}
+				return err.Error()
+			}
+		}
+		return ""
+	}
+
+	validateTest := func(t *testing.T, tt routeConflictTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%v) failed with %q, want: %q", TEST_FUNCTION, tt.patterns, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestRouterHandleAfterRejectedRoute(t *testing.T) {
+	const TEST_FUNCTION = "Router.Handle"
+
+	// Every pattern but the first and the last is rejected, and must leave
+	// nothing behind that the last one could conflict with.
+	tests := []routeConflictTest{
+		{
+			testingutil.BasicTest{Description: "After a parameter used twice", Want: ""},
+			[]string{"/", "/a/{x:int}/{x}", "/a/{y:int}"},
+		},
+		{
+			testingutil.BasicTest{Description: "After a misplaced catch-all", Want: ""},
+			[]string{"/", "/a/{x}/{rest...}/edit", "/a/{y}"},
+		},
+		{
+			testingutil.BasicTest{Description: "After a parameter used twice below a route", Want: ""},
+			[]string{"/c/{x}", "/c/{x}/d/{z}/{z}", "/c/{x}/d/{w}"},
+		},
+	}
+
+	executeTest := func(t *testing.T, tt routeConflictTest) string {
+		r := NewRouter()
+		last := len(tt.patterns) - 1
+		if err := r.Handle(GET, tt.patterns[0], nil); err != nil {
+			t.Fatalf("%s(%s) returned '%s'", TEST_FUNCTION, tt.patterns[0], err)
+		}
+		for _, pattern := range tt.patterns[1:last] {
+			if err := r.Handle(GET, pattern, nil); err == nil {
+				t.Errorf("%s(%s) succeeded, want an error", TEST_FUNCTION, pattern)
+			}
+		}
+
+		if err := r.Handle(GET, tt.patterns[last], nil); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	validateTest := func(t *testing.T, tt routeConflictTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%v) failed with %q, want: %q", TEST_FUNCTION, tt.patterns, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestRouteConnectionStatus(t *testing.T) {
+	const TEST_FUNCTION = "RouteConnection"
+
+	tests := []routeMatchTest{
+		{testingutil.BasicTest{Description: "Matching route", Want: network.STATUS_OK}, "GET /hello"},
+		{testingutil.BasicTest{Description: "HEAD is served by GET", Want: network.STATUS_OK}, "HEAD /hello"},
+		{testingutil.BasicTest{Description: "Unknown path", Want: network.STATUS_NOT_FOUND}, "GET /nope"},
+		{testingutil.BasicTest{Description: "Wrong method", Want: network.STATUS_METHOD_NOT_ALLOWED}, "DELETE /hello"},
+		{testingutil.BasicTest{Description: "Constraint does not match", Want: network.STATUS_NOT_FOUND}, "GET /users/abc"},
+		{testingutil.BasicTest{Description: "OPTIONS", Want: network.STATUS_NO_CONTENT}, "OPTIONS /hello"},
+		{testingutil.BasicTest{Description: "Invalid query escape", Want: network.STATUS_BAD_REQUEST}, "GET /hello?name=%zz"},
+		{testingutil.BasicTest{Description: "Query value is not an int", Want: network.STATUS_BAD_REQUEST}, "GET /users?id=abc"},
+		{testingutil.BasicTest{Description: "Invalid path escape", Want: network.STATUS_BAD_REQUEST}, "GET /users/%zz"},
+		{testingutil.BasicTest{Description: "Encoded slash", Want: network.STATUS_BAD_REQUEST}, "GET /users/a%2Fb"},
+	}
+
+	db, err := database.Open(database.MEMORY_URL)
+	if err != nil {
+		t.Fatalf("database.Open(%s) returned '%s'", database.MEMORY_URL, err)
+	}
+	defer db.Close()
+
+	users, err := userrepository.NewUserRepository(db)
+	if err != nil {
+		t.Fatalf("NewUserRepository returned '%s'", err)
+	}
+
+	router, err := NewDefaultRouter(users)
+	if err != nil {
+		t.Fatalf("NewDefaultRouter returned '%s'", err)
+	}
+
+	executeTest := func(t *testing.T, tt routeMatchTest) int {
+		method, path, _ := strings.Cut(tt.path, " ")
+		w := &statusRecorder{header: models.Header{}}
+		router.RouteConnection(w, models.HttpRequest{Method: method, Path: path, Version: "HTTP/1.1"})
+		return w.status
+	}
+
+	validateTest := func(t *testing.T, tt routeMatchTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[int](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%s) answered %d, want: %d", TEST_FUNCTION, tt.path, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestAllowHeader(t *testing.T) {
+	const TEST_FUNCTION = "RouteConnection"
+
+	tests := []routeMatchTest{
+		{testingutil.BasicTest{Description: "Wrong method", Want: "405 GET, HEAD, OPTIONS"}, "DELETE /hello"},
+		{testingutil.BasicTest{Description: "Wrong method on a path with several routes", Want: "405 GET, HEAD, PATCH, OPTIONS"}, "DELETE /users/1"},
+		{testingutil.BasicTest{Description: "Wrong method on a POST route", Want: "405 POST, OPTIONS"}, "GET /users/create"},
+		{testingutil.BasicTest{Description: "OPTIONS of a path", Want: "204 GET, HEAD, PATCH, OPTIONS"}, "OPTIONS /users/1"},
+		{testingutil.BasicTest{Description: "OPTIONS of a GET route", Want: "204 GET, HEAD, OPTIONS"}, "OPTIONS /hello"},
+		{testingutil.BasicTest{Description: "OPTIONS of the server", Want: "204 GET, HEAD, POST, PATCH, OPTIONS"}, "OPTIONS *"},
+	}
+
+	db, err := database.Open(database.MEMORY_URL)
+	if err != nil {
+		t.Fatalf("database.Open(%s) returned '%s'", database.MEMORY_URL, err)
+	}
+	defer db.Close()
+
+	users, err := userrepository.NewUserRepository(db)
+	if err != nil {
+		t.Fatalf("NewUserRepository returned '%s'", err)
+	}
+
+	router, err := NewDefaultRouter(users)
+	if err != nil {
+		t.Fatalf("NewDefaultRouter returned '%s'", err)
+	}
+
+	executeTest := func(t *testing.T, tt routeMatchTest) string {
+		method, path, _ := strings.Cut(tt.path, " ")
+		w := &statusRecorder{header: models.Header{}}
+		router.RouteConnection(w, models.HttpRequest{Method: method, Path: path, Version: "HTTP/1.1"})
+		return fmt.Sprintf("%d %s", w.Status(), w.header.Get("Allow"))
+	}
+
+	validateTest := func(t *testing.T, tt routeMatchTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%s) answered %q, want: %q", TEST_FUNCTION, tt.path, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+// statusRecorder is a ResponseWriter that only records the status and the
+// number of body bytes.
+type statusRecorder struct {
+	header  models.Header
+	status  int
+	written int64
+}
+
+func (w *statusRecorder) Header() models.Header {
+	return w.header
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *statusRecorder) Write(data []byte) (int, error) {
+	w.WriteHeader(network.STATUS_OK)
+	w.written += int64(len(data))
+	return len(data), nil
+}
+
+func (w *statusRecorder) Flush() error {
+	return nil
+}
+
+func (w *statusRecorder) Status() int {
+	if w.status == 0 {
+		return network.STATUS_OK
+	}
+	return w.status
+}
+
+func (w *statusRecorder) Written() int64 {
+	return w.written
+}
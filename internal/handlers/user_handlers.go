@@ -2,82 +2,155 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	userrepository "http-server/internal/data/repositories/user"
 	"http-server/internal/models"
 	"http-server/internal/network"
-	"net"
+	"mime"
 	"strconv"
 )
 
-var userRepository = userrepository.NewUserRepository()
+// userHandlers serves the user endpoints from a repository.
+type userHandlers struct {
+	userRepository userrepository.UserRepository
+}
 
-func registerUserHandlers() {
-	registerHandler(POST, "/users/create", createUser)
-	registerHandler(GET, "/users/{id}", getUserByIdAsPathVariable)
-	registerHandler(GET, "/users", getUserByIdAsQuery)
+// NewUserRouter returns a Router with the user endpoints relative to where it
+// is mounted, which store users in users.
+func NewUserRouter(users userrepository.UserRepository) (*Router, error) {
+	h := &userHandlers{userRepository: users}
+
+	return newRouterWith([]route{
+		{POST, "/create", h.createUser},
+		{GET, "/{id:int}", h.getUserByIdAsPathVariable},
+		{GET, "/", h.getUserByIdAsQuery},
+		{PATCH, "/{id:int}", h.updateUser},
+	})
 }
 
-func getUserByIdAsQuery(conn net.Conn, http models.HttpRequest) {
+func (h *userHandlers) getUserByIdAsQuery(w network.ResponseWriter, http models.HttpRequest) {
 	key := "id"
-	id, err := strconv.Atoi(http.Query[key])
-	var response string
+	if !http.Query.Has(key) {
+		network.SendText(w, network.STATUS_BAD_REQUEST, fmt.Sprintf("missing query key: %s", key))
+		return
+	}
 
+	id, err := http.Query.Int(key, 0)
 	if err != nil {
-		response = network.RESPONSE_BAD_REQUEST + network.CRLF + fmt.Sprintf("missing query key: %s", key)
-		network.SendData(response, conn)
+		network.SendText(w, network.STATUS_BAD_REQUEST, err.Error())
 		return
 	}
 
 	data := models.User{Id: id}
-	user, err := userRepository.GetUserById(data.Id)
+	user, err := h.userRepository.GetUserById(http.Context(), data.Id)
 
 	if err != nil {
-		response = network.RESPONSE_BAD_REQUEST + network.CRLF + err.Error()
+		network.SendText(w, network.STATUS_BAD_REQUEST, err.Error())
 	} else {
-		userJson, _ := json.Marshal(user)
-		response = network.RESPONSE_OK + network.CRLF + string(userJson)
+		network.SendJSON(w, network.STATUS_OK, user)
 	}
-
-	network.SendData(response, conn)
 }
 
-func getUserByIdAsPathVariable(conn net.Conn, http models.HttpRequest) {
+func (h *userHandlers) getUserByIdAsPathVariable(w network.ResponseWriter, http models.HttpRequest) {
 	key := "id"
 	id, err := strconv.Atoi(http.PathVariables[key])
-	var response string
 
 	if err != nil {
-		response = network.RESPONSE_BAD_REQUEST + network.CRLF + fmt.Sprintf("missing path variable: %s", key)
-		network.SendData(response, conn)
+		network.SendText(w, network.STATUS_BAD_REQUEST, fmt.Sprintf("missing path variable: %s", key))
 		return
 	}
 
 	data := models.User{Id: id}
-	user, err := userRepository.GetUserById(data.Id)
+	user, err := h.userRepository.GetUserById(http.Context(), data.Id)
 
 	if err != nil {
-		response = network.RESPONSE_BAD_REQUEST + network.CRLF + "not working"
+		network.SendText(w, network.STATUS_BAD_REQUEST, "not working")
 	} else {
-		userJson, _ := json.Marshal(user)
-		response = network.RESPONSE_OK + network.CRLF + string(userJson)
+		network.SendJSON(w, network.STATUS_OK, user)
+	}
+}
+
+// createUser creates a user from a JSON body or from a form with the fields
+// "username" and "password".
+func (h *userHandlers) createUser(w network.ResponseWriter, http models.HttpRequest) {
+	data, err := decodeUser(&http)
+	if err != nil {
+		sendBodyError(w, err)
+		return
 	}
 
-	network.SendData(response, conn)
+	if err := h.userRepository.CreateUser(http.Context(), data.Username, data.Password); err != nil {
+		network.SendText(w, network.STATUS_BAD_REQUEST, err.Error())
+	} else {
+		network.SendText(w, network.STATUS_OK, "Created user")
+	}
 }
 
-func createUser(conn net.Conn, http models.HttpRequest) {
-	// dao := database.GetDao()
-	data := new(models.User)
-	json.Unmarshal([]byte(http.Body), &data)
-	var response string
+// updateUser changes the fields present in the JSON or form body of the
+// request and answers with the updated user.
+func (h *userHandlers) updateUser(w network.ResponseWriter, http models.HttpRequest) {
+	key := "id"
+	id, err := strconv.Atoi(http.PathVariables[key])
 
-	if err := userRepository.CreateUser(data.Username, data.Password); err != nil {
+	if err != nil {
+		network.SendText(w, network.STATUS_BAD_REQUEST, fmt.Sprintf("missing path variable: %s", key))
+		return
+	}
 
-		response = network.RESPONSE_BAD_REQUEST + network.CRLF + err.Error()
+	data, err := decodeUser(&http)
+	if err != nil {
+		sendBodyError(w, err)
+		return
+	}
+
+	if err := h.userRepository.UpdateUser(http.Context(), id, data.Username, data.Password); err != nil {
+		network.SendText(w, network.STATUS_BAD_REQUEST, err.Error())
+		return
+	}
+
+	if user, err := h.userRepository.GetUserById(http.Context(), id); err != nil {
+		network.SendText(w, network.STATUS_BAD_REQUEST, err.Error())
 	} else {
-		response = network.RESPONSE_OK + network.CRLF + "Created user"
+		network.SendJSON(w, network.STATUS_OK, user)
+	}
+}
+
+var errInvalidJSON = errors.New("invalid JSON body")
+
+// decodeUser reads the user in the body of http, which is either a form or
+// JSON.
+func decodeUser(http *models.HttpRequest) (*models.User, error) {
+	mediaType, _, _ := mime.ParseMediaType(http.Headers.Get("Content-Type"))
+	if mediaType == models.FORM_URLENCODED || mediaType == models.FORM_MULTIPART {
+		if err := http.ParseForm(); err != nil {
+			return nil, err
+		}
+		return &models.User{Username: http.Form.Get("username"), Password: http.Form.Get("password")}, nil
 	}
 
-	network.SendData(response, conn)
+	body, err := http.ReadBody()
+	if err != nil {
+		return nil, err
+	}
+
+	data := new(models.User)
+	if err := json.Unmarshal(body, data); err != nil {
+		return nil, errInvalidJSON
+	}
+	return data, nil
+}
+
+// sendBodyError answers a request whose body could not be decoded. Errors
+// reading the body itself carry the status to answer with.
+func sendBodyError(w network.ResponseWriter, err error) {
+	status := network.STATUS_BAD_REQUEST
+	var requestErr *network.RequestError
+	switch {
+	case errors.As(err, &requestErr):
+		status = requestErr.Status
+	case errors.Is(err, models.ErrPartTooLarge) || errors.Is(err, models.ErrFormTooLarge):
+		status = network.STATUS_CONTENT_TOO_LARGE
+	}
+	network.SendText(w, status, err.Error())
 }
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"http-server/internal/data/database"
+	userrepository "http-server/internal/data/repositories/user"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	testingutil "http-server/internal/util/testing"
+	"testing"
+)
+
+type createUserTest struct {
+	testingutil.BasicTest
+	contentType string
+	body        string
+}
+
+func (test createUserTest) String() string {
+	return test.Description
+}
+
+func TestCreateUser(t *testing.T) {
+	const TEST_FUNCTION = "userHandlers.createUser"
+
+	db, err := database.Open(database.MEMORY_URL)
+	if err != nil {
+		t.Fatalf("database.Open(%s) returned '%s'", database.MEMORY_URL, err)
+	}
+	defer db.Close()
+
+	users, err := userrepository.NewUserRepository(db)
+	if err != nil {
+		t.Fatalf("NewUserRepository returned '%s'", err)
+	}
+
+	router, err := NewUserRouter(users)
+	if err != nil {
+		t.Fatalf("NewUserRouter returned '%s'", err)
+	}
+
+	tests := []createUserTest{
+		{
+			testingutil.BasicTest{Description: "JSON body", Want: network.STATUS_OK},
+			"application/json", `{"username":"json","password":"secret"}`,
+		},
+		{
+			testingutil.BasicTest{Description: "JSON body without a Content-Type", Want: network.STATUS_OK},
+			"", `{"username":"plain","password":"secret"}`,
+		},
+		{
+			testingutil.BasicTest{Description: "Invalid JSON body", Want: network.STATUS_BAD_REQUEST},
+			"application/json", `{"username":`,
+		},
+		{
+			testingutil.BasicTest{Description: "URL-encoded form", Want: network.STATUS_OK},
+			models.FORM_URLENCODED, "username=url+encoded&password=secret",
+		},
+		{
+			testingutil.BasicTest{Description: "Multipart form", Want: network.STATUS_OK},
+			"multipart/form-data; boundary=xyz",
+			"--xyz\r\nContent-Disposition: form-data; name=\"username\"\r\n\r\nmultipart\r\n" +
+				"--xyz\r\nContent-Disposition: form-data; name=\"password\"\r\n\r\nsecret\r\n--xyz--\r\n",
+		},
+		{
+			testingutil.BasicTest{Description: "Multipart form without a boundary", Want: network.STATUS_BAD_REQUEST},
+			models.FORM_MULTIPART, "--xyz--\r\n",
+		},
+	}
+
+	executeTest := func(t *testing.T, tt createUserTest) int {
+		request := models.HttpRequest{Method: POST, Path: "/create", Version: "HTTP/1.1", Headers: models.Header{}, Body: models.StringBody(tt.body)}
+		if tt.contentType != "" {
+			request.Headers.Set("Content-Type", tt.contentType)
+		}
+
+		w := &statusRecorder{header: models.Header{}}
+		router.RouteConnection(w, request)
+		return w.Status()
+	}
+
+	validateTest := func(t *testing.T, tt createUserTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[int](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%q) answered %d, want: %d", TEST_FUNCTION, tt.body, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
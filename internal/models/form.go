@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"mime"
+)
+
+const (
+	FORM_URLENCODED = "application/x-www-form-urlencoded"
+	FORM_MULTIPART  = "multipart/form-data"
+)
+
+var ErrNotForm = errors.New("request body is not a form")
+
+// ParseForm parses the body of a request sent with an HTML form into
+// r.Form, if it has not been parsed yet. Bodies of type
+// application/x-www-form-urlencoded are decoded with the package function
+// ParseForm, and multipart/form-data bodies are parsed with
+// ParseMultipartForm and DefaultMultipartLimits. Requests with another
+// Content-Type get an empty Form and ErrNotForm.
+// Values from the query string are not included; they are in r.Query.
+// The body can only be read once, so later calls return the error of the
+// first one.
+func (r *HttpRequest) ParseForm() error {
+	if r.Form != nil {
+		return r.formErr
+	}
+
+	r.Form, r.formErr = r.parseForm()
+	return r.formErr
+}
+
+// parseForm reads the form in the body. It returns the values that could be
+// decoded even if it fails.
+func (r *HttpRequest) parseForm() (Query, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Headers.Get("Content-Type"))
+	switch mediaType {
+	case FORM_URLENCODED:
+		body, err := r.ReadBodyString()
+		if err != nil {
+			return Query{}, err
+		}
+		return ParseForm(body)
+	case FORM_MULTIPART:
+		if err := r.ParseMultipartForm(DefaultMultipartLimits); err != nil {
+			return Query{}, err
+		}
+		return r.Form, nil
+	}
+
+	return Query{}, ErrNotForm
+}
+
+// FormValue returns the first value of key in the form of the request,
+// parsing it first if needed. Parsing errors are ignored, so it returns ""
+// if the body is not a valid form; call ParseForm to tell the cases apart.
+func (r *HttpRequest) FormValue(key string) string {
+	r.ParseForm()
+	return r.Form.Get(key)
+}
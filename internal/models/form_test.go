@@ -0,0 +1,251 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	testingutil "http-server/internal/util/testing"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type formTest struct {
+	testingutil.BasicTest
+	contentType string
+	body        string
+	limits      MultipartLimits
+}
+
+func (test formTest) String() string {
+	return test.Description
+}
+
+// multipartBody builds a multipart/form-data body with the boundary "xyz"
+// from parts written as "name=value" or "name:filename=content".
+func multipartBody(parts ...string) string {
+	var sb strings.Builder
+	for _, part := range parts {
+		field, content, _ := strings.Cut(part, "=")
+		name, filename, isFile := strings.Cut(field, ":")
+
+		sb.WriteString("--xyz\r\n")
+		if isFile {
+			fmt.Fprintf(&sb, "Content-Disposition: form-data; name=%q; filename=%q\r\n", name, filename)
+			sb.WriteString("Content-Type: text/plain\r\n")
+		} else {
+			fmt.Fprintf(&sb, "Content-Disposition: form-data; name=%q\r\n", name)
+		}
+		sb.WriteString("\r\n" + content + "\r\n")
+	}
+	sb.WriteString("--xyz--\r\n")
+	return sb.String()
+}
+
+const MULTIPART_TYPE = "multipart/form-data; boundary=xyz"
+
+func TestParseForm(t *testing.T) {
+	const TEST_FUNCTION = "HttpRequest.ParseForm"
+
+	tests := []formTest{
+		{
+			testingutil.BasicTest{Description: "URL-encoded form", Want: "map[name:[a b] tag:[x y]]"},
+			FORM_URLENCODED, "name=a+b&tag=x&tag=%79", MultipartLimits{},
+		},
+		{
+			testingutil.BasicTest{Description: "URL-encoded form with charset", Want: "map[name:[a]]"},
+			FORM_URLENCODED + "; charset=utf-8", "name=a", MultipartLimits{},
+		},
+		{
+			testingutil.BasicTest{Description: "Invalid URL-encoded form", Want: "map[b:[2]]", Error: `invalid query parameter "a=%zz": invalid URL escape "%zz"`},
+			FORM_URLENCODED, "a=%zz&b=2", MultipartLimits{},
+		},
+		{
+			testingutil.BasicTest{Description: "Multipart values", Want: "map[name:[a b] tag:[x y]]"},
+			MULTIPART_TYPE, multipartBody("name=a b", "tag=x", "tag=y"), MultipartLimits{},
+		},
+		{
+			testingutil.BasicTest{Description: "Multipart files are not values", Want: "map[name:[a]]"},
+			MULTIPART_TYPE, multipartBody("name=a", "upload:a.txt=hello"), MultipartLimits{},
+		},
+		{
+			testingutil.BasicTest{Description: "Multipart without a boundary", Want: "map[]", Error: ErrMissingBoundary.Error()},
+			FORM_MULTIPART, multipartBody("name=a"), MultipartLimits{},
+		},
+		{
+			testingutil.BasicTest{Description: "Multipart with a wrong boundary", Want: "map[]", Error: "multipart: NextPart: EOF"},
+			"multipart/form-data; boundary=abc", multipartBody("name=a"), MultipartLimits{},
+		},
+		{
+			testingutil.BasicTest{Description: "Part too large", Want: "map[]", Error: ErrPartTooLarge.Error()},
+			MULTIPART_TYPE, multipartBody("name=abcdef"), MultipartLimits{MaxPartBytes: 5},
+		},
+		{
+			testingutil.BasicTest{Description: "Part of exactly the maximum size", Want: "map[name:[abcde]]"},
+			MULTIPART_TYPE, multipartBody("name=abcde"), MultipartLimits{MaxPartBytes: 5},
+		},
+		{
+			testingutil.BasicTest{Description: "Form too large", Want: "map[]", Error: ErrFormTooLarge.Error()},
+			MULTIPART_TYPE, multipartBody("a=1234", "b=1234", "c=1234"), MultipartLimits{MaxTotalBytes: 10},
+		},
+		{
+			testingutil.BasicTest{Description: "File too large", Want: "map[]", Error: ErrPartTooLarge.Error()},
+			MULTIPART_TYPE, multipartBody("upload:a.txt=0123456789"), MultipartLimits{MaxMemoryBytes: 2, MaxPartBytes: 5},
+		},
+		{
+			testingutil.BasicTest{Description: "Field after a file that fills the memory", Want: "map[username:[bob]]"},
+			MULTIPART_TYPE, multipartBody("upload:a.txt=abcd", "username=bob"), MultipartLimits{MaxMemoryBytes: 4},
+		},
+		{
+			testingutil.BasicTest{Description: "Field larger than the memory for files", Want: "map[name:[abcdef]]"},
+			MULTIPART_TYPE, multipartBody("name=abcdef"), MultipartLimits{MaxMemoryBytes: 2},
+		},
+		{
+			testingutil.BasicTest{Description: "Too many parts", Want: "map[]", Error: ErrTooManyParts.Error()},
+			MULTIPART_TYPE, multipartBody("a=1", "b=2", "c=3"), MultipartLimits{MaxParts: 2},
+		},
+		{
+			testingutil.BasicTest{Description: "JSON body", Want: "map[]", Error: ErrNotForm.Error()},
+			"application/json", `{"name":"a"}`, MultipartLimits{},
+		},
+	}
+
+	executeTest := func(t *testing.T, tt formTest) string {
+		request := HttpRequest{Headers: Header{}, Body: StringBody(tt.body)}
+		request.Headers.Set("Content-Type", tt.contentType)
+
+		var err error
+		if strings.HasPrefix(tt.contentType, FORM_MULTIPART) {
+			err = request.ParseMultipartForm(tt.limits)
+			if err != nil {
+				request.Form = Query{}
+			}
+		} else {
+			err = request.ParseForm()
+		}
+
+		if tt.Error == "" && err != nil {
+			t.Errorf("%s returned '%s'", TEST_FUNCTION, err)
+		} else if tt.Error != "" && err == nil {
+			t.Errorf("%s returned no error, want: '%s'", TEST_FUNCTION, tt.Error)
+		}
+		testingutil.ValidateError(t, TEST_FUNCTION, err, tt.Error)
+
+		return fmt.Sprint(request.Form)
+	}
+
+	validateTest := func(t *testing.T, tt formTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%q) = %s, want: %s", TEST_FUNCTION, tt.body, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestParseFormTwice(t *testing.T) {
+	const TEST_FUNCTION = "HttpRequest.ParseForm"
+
+	tests := []formTest{
+		{
+			testingutil.BasicTest{Description: "Invalid URL-encoded form", Want: "2", Error: `invalid query parameter "a=%zz": invalid URL escape "%zz"`},
+			FORM_URLENCODED, "a=%zz&b=2", MultipartLimits{},
+		},
+		{
+			testingutil.BasicTest{Description: "JSON body", Want: "", Error: ErrNotForm.Error()},
+			"application/json", `{"b":"2"}`, MultipartLimits{},
+		},
+		{
+			testingutil.BasicTest{Description: "Multipart without a boundary", Want: "", Error: ErrMissingBoundary.Error()},
+			FORM_MULTIPART, multipartBody("b=2"), MultipartLimits{},
+		},
+		{
+			testingutil.BasicTest{Description: "Valid form", Want: "2"},
+			FORM_URLENCODED, "b=2", MultipartLimits{},
+		},
+	}
+
+	executeTest := func(t *testing.T, tt formTest) string {
+		request := HttpRequest{Headers: Header{}, Body: StringBody(tt.body)}
+		request.Headers.Set("Content-Type", tt.contentType)
+
+		// FormValue parses the form first and hides the error, which the next
+		// call to ParseForm must still return.
+		value := request.FormValue("b")
+		for i := 0; i < 2; i++ {
+			err := request.ParseForm()
+			if tt.Error != "" && err == nil {
+				t.Errorf("%s call %d returned no error, want: '%s'", TEST_FUNCTION, i+1, tt.Error)
+			}
+			testingutil.ValidateError(t, TEST_FUNCTION, err, tt.Error)
+		}
+
+		return value
+	}
+
+	validateTest := func(t *testing.T, tt formTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("HttpRequest.FormValue(%q) = %q, want: %q", "b", got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestMultipartFiles(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	request := HttpRequest{Headers: Header{}, Body: StringBody(multipartBody("small:a.txt=hi", "large:b.txt=0123456789", "large:c.txt=abcdefghij"))}
+	request.Headers.Set("Content-Type", MULTIPART_TYPE)
+	request = request.WithContext(ctx)
+
+	if err := request.ParseMultipartForm(MultipartLimits{MaxMemoryBytes: 4}); err != nil {
+		t.Fatalf("HttpRequest.ParseMultipartForm returned '%s'", err)
+	}
+
+	got := []string{}
+	tmpfiles := []string{}
+	for name, files := range request.MultipartForm.File {
+		for _, file := range files {
+			reader, err := file.Open()
+			if err != nil {
+				t.Fatalf("FileHeader.Open of %s returned '%s'", file.Filename, err)
+			}
+			content, _ := io.ReadAll(reader)
+			reader.Close()
+
+			got = append(got, fmt.Sprintf("%s:%s=%s (%d, %s)", name, file.Filename, content, file.Size, file.Header.Get("Content-Type")))
+			if file.tmpfile != "" {
+				tmpfiles = append(tmpfiles, file.tmpfile)
+			}
+		}
+	}
+	sort.Strings(got)
+
+	want := "large:b.txt=0123456789 (10, text/plain)|large:c.txt=abcdefghij (10, text/plain)|small:a.txt=hi (2, text/plain)"
+	if strings.Join(got, "|") != want {
+		t.Errorf("HttpRequest.MultipartForm.File = %q, want: %q", strings.Join(got, "|"), want)
+	}
+	if len(tmpfiles) != 2 {
+		t.Fatalf("%d files were written to temporary files, want: 2", len(tmpfiles))
+	}
+
+	cancel()
+	for _, tmpfile := range tmpfiles {
+		removed := false
+		for i := 0; i < 100 && !removed; i++ {
+			_, err := os.Stat(tmpfile)
+			removed = errors.Is(err, os.ErrNotExist)
+			if !removed {
+				time.Sleep(time.Millisecond)
+			}
+		}
+		if !removed {
+			t.Errorf("temporary file %s was not removed when the request context ended", tmpfile)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package models
+
+import "net/textproto"
+
+// Header maps canonical header field names to their values.
+// Field names are case-insensitive, so all access should go through the
+// methods, which canonicalize the name first.
+type Header map[string][]string
+
+// Get returns the first value associated with name, or "" if there is none.
+func (h Header) Get(name string) string {
+	if values := h[textproto.CanonicalMIMEHeaderKey(name)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Values returns all values associated with name.
+func (h Header) Values(name string) []string {
+	return h[textproto.CanonicalMIMEHeaderKey(name)]
+}
+
+// Has reports whether at least one value is associated with name.
+func (h Header) Has(name string) bool {
+	_, found := h[textproto.CanonicalMIMEHeaderKey(name)]
+	return found
+}
+
+// Set replaces any existing values associated with name by value.
+func (h Header) Set(name, value string) {
+	h[textproto.CanonicalMIMEHeaderKey(name)] = []string{value}
+}
+
+// Add appends value to the values associated with name.
+func (h Header) Add(name, value string) {
+	key := textproto.CanonicalMIMEHeaderKey(name)
+	h[key] = append(h[key], value)
+}
+
+// Del removes all values associated with name.
+func (h Header) Del(name string) {
+	delete(h, textproto.CanonicalMIMEHeaderKey(name))
+}
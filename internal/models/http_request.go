@@ -1,11 +1,76 @@
 package models
 
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+)
+
 type HttpRequest struct {
-	Method        string
-	Path          string
-	Version       string
-	Headers       string
-	Body          string
+	Method   string
+	Path     string
+	Version  string
+	Headers  Header
+	Trailers Header
+	// Body streams the body of the request from the connection. It is read
+	// at most once, and is empty for requests without a body.
+	Body          io.ReadCloser
 	PathVariables map[string]string
-	Query         map[string]string
+	Query         Query
+
+	// Form holds the values of a form body once ParseForm has been called,
+	// and MultipartForm the files of a multipart one.
+	Form          Query
+	MultipartForm *MultipartForm
+
+	ctx     context.Context
+	formErr error
+}
+
+// Context returns the context of the request. It is cancelled when the client
+// disconnects, when the server stops waiting for the request during shutdown
+// or when a deadline set for the route passes. Requests that were not given
+// a context return context.Background().
+func (r HttpRequest) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// WithContext returns a copy of the request with its context replaced by ctx.
+func (r HttpRequest) WithContext(ctx context.Context) HttpRequest {
+	r.ctx = ctx
+	return r
+}
+
+// ReadBody reads the rest of the body into memory.
+func (r HttpRequest) ReadBody() ([]byte, error) {
+	return io.ReadAll(r.body())
+}
+
+// ReadBodyString reads the rest of the body into a string.
+func (r HttpRequest) ReadBodyString() (string, error) {
+	body, err := r.ReadBody()
+	return string(body), err
+}
+
+// body returns the Body of the request, or an empty reader if it has none.
+func (r HttpRequest) body() io.Reader {
+	if r.Body == nil {
+		return strings.NewReader("")
+	}
+	return r.Body
+}
+
+// StringBody returns a Body that reads s, for requests that are built rather
+// than read from a connection.
+func StringBody(s string) io.ReadCloser {
+	return io.NopCloser(strings.NewReader(s))
+}
+
+// BytesBody returns a Body that reads b.
+func BytesBody(b []byte) io.ReadCloser {
+	return io.NopCloser(bytes.NewReader(b))
 }
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+)
+
+// MultipartLimits bounds what ParseMultipartForm is willing to read.
+// Zero fields fall back to the value in DefaultMultipartLimits.
+type MultipartLimits struct {
+	// MaxMemoryBytes bounds how much of the uploaded files is kept in memory.
+	// Files that do not fit are written to temporary files. Text fields are
+	// always kept in memory and only bounded by MaxPartBytes and MaxTotalBytes.
+	MaxMemoryBytes int64
+
+	// MaxPartBytes bounds the size of a single part.
+	MaxPartBytes int64
+
+	// MaxTotalBytes bounds the size of all parts together.
+	MaxTotalBytes int64
+
+	// MaxParts bounds the number of parts.
+	MaxParts int
+}
+
+var DefaultMultipartLimits = MultipartLimits{
+	MaxMemoryBytes: 1 << 20,
+	MaxPartBytes:   8 << 20,
+	MaxTotalBytes:  32 << 20,
+	MaxParts:       100,
+}
+
+var (
+	ErrNotMultipart    = errors.New("request body is not multipart/form-data")
+	ErrMissingBoundary = errors.New("multipart/form-data without a boundary")
+	ErrPartTooLarge    = errors.New("multipart part too large")
+	ErrFormTooLarge    = errors.New("multipart form too large")
+	ErrTooManyParts    = errors.New("too many multipart parts")
+)
+
+func (l MultipartLimits) withDefaults() MultipartLimits {
+	if l.MaxMemoryBytes <= 0 {
+		l.MaxMemoryBytes = DefaultMultipartLimits.MaxMemoryBytes
+	}
+	if l.MaxPartBytes <= 0 {
+		l.MaxPartBytes = DefaultMultipartLimits.MaxPartBytes
+	}
+	if l.MaxTotalBytes <= 0 {
+		l.MaxTotalBytes = DefaultMultipartLimits.MaxTotalBytes
+	}
+	if l.MaxParts <= 0 {
+		l.MaxParts = DefaultMultipartLimits.MaxParts
+	}
+	return l
+}
+
+// MultipartForm is a parsed multipart/form-data body.
+type MultipartForm struct {
+	// Value holds the parts that are not files.
+	Value Query
+	// File holds the parts with a file name, by form field name.
+	File map[string][]*FileHeader
+}
+
+// FileHeader describes a file part of a multipart form.
+type FileHeader struct {
+	Filename string
+	Header   Header
+	Size     int64
+
+	content []byte
+	tmpfile string
+}
+
+// Open returns the content of the file.
+func (f *FileHeader) Open() (io.ReadCloser, error) {
+	if f.tmpfile != "" {
+		return os.Open(f.tmpfile)
+	}
+	return io.NopCloser(bytes.NewReader(f.content)), nil
+}
+
+// RemoveAll removes the temporary files of the form.
+func (f *MultipartForm) RemoveAll() error {
+	var err error
+	for _, files := range f.File {
+		for _, file := range files {
+			if file.tmpfile == "" {
+				continue
+			}
+			if removeErr := os.Remove(file.tmpfile); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) && err == nil {
+				err = removeErr
+			}
+		}
+	}
+	return err
+}
+
+// ParseMultipartForm parses a multipart/form-data body into r.MultipartForm,
+// if it has not been parsed yet, and puts its values in r.Form.
+// Files are kept in memory up to limits.MaxMemoryBytes in total, and the
+// rest are written to temporary files, which are removed once the context
+// of the request is done.
+func (r *HttpRequest) ParseMultipartForm(limits MultipartLimits) error {
+	if r.MultipartForm != nil {
+		return nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(r.Headers.Get("Content-Type"))
+	if err != nil || mediaType != FORM_MULTIPART {
+		return ErrNotMultipart
+	}
+
+	boundary := params["boundary"]
+	if boundary == "" {
+		return ErrMissingBoundary
+	}
+
+	form := &MultipartForm{Value: Query{}, File: map[string][]*FileHeader{}}
+	if err := readMultipart(multipart.NewReader(r.body(), boundary), form, limits.withDefaults()); err != nil {
+		form.RemoveAll()
+		return err
+	}
+
+	context.AfterFunc(r.Context(), func() {
+		form.RemoveAll()
+	})
+
+	r.MultipartForm = form
+	r.Form = form.Value
+	return nil
+}
+
+// readMultipart reads every part of reader into form.
+func readMultipart(reader *multipart.Reader, form *MultipartForm, limits MultipartLimits) error {
+	memory := limits.MaxMemoryBytes
+	total := limits.MaxTotalBytes
+
+	for parts := 0; ; parts++ {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if parts == limits.MaxParts {
+			return ErrTooManyParts
+		}
+
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+
+		// Text fields are always kept in memory, so only files are bounded by
+		// what is left of the memory budget while they are read.
+		filename := part.FileName()
+		allowed := min(limits.MaxPartBytes, total)
+		inMemory := allowed
+		if filename != "" {
+			inMemory = min(allowed, memory)
+		}
+
+		// One byte more than allowed tells a part that is too large apart from
+		// one that has exactly the allowed size.
+		var buffer bytes.Buffer
+		n, err := io.CopyN(&buffer, part, inMemory+1)
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if n > allowed {
+			if allowed == total {
+				return ErrFormTooLarge
+			}
+			return ErrPartTooLarge
+		}
+
+		if filename == "" {
+			form.Value.Add(name, buffer.String())
+			total -= n
+			continue
+		}
+
+		file := &FileHeader{Filename: filename, Header: Header(part.Header), Size: n}
+		if n <= memory {
+			file.content = buffer.Bytes()
+			memory -= n
+		} else {
+			// The file does not fit in memory, so it continues on disk.
+			if file.Size, err = spill(file, &buffer, part, allowed); err != nil {
+				return err
+			}
+			if file.Size > allowed {
+				os.Remove(file.tmpfile)
+				if allowed == total {
+					return ErrFormTooLarge
+				}
+				return ErrPartTooLarge
+			}
+		}
+
+		form.File[name] = append(form.File[name], file)
+		total -= file.Size
+	}
+}
+
+// spill writes the start of a file that was read into buffer and the rest of
+// part to a temporary file, reading at most one byte more than allowed.
+func spill(file *FileHeader, buffer *bytes.Buffer, part io.Reader, allowed int64) (int64, error) {
+	tmp, err := os.CreateTemp("", "multipart-")
+	if err != nil {
+		return 0, err
+	}
+	defer tmp.Close()
+	file.tmpfile = tmp.Name()
+
+	n, err := io.Copy(tmp, io.MultiReader(buffer, io.LimitReader(part, allowed+1-int64(buffer.Len()))))
+	if err != nil {
+		os.Remove(file.tmpfile)
+		return 0, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Query maps the keys of a query string to their values, in the order they
+// appeared. A key without "=" has a single empty value.
+type Query map[string][]string
+
+// QueryError reports a query parameter whose value cannot be converted to the
+// type a handler asked for.
+type QueryError struct {
+	Key   string
+	Value string
+	Err   error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("invalid value %q for query parameter %q: %v", e.Value, e.Key, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// ParseQuery decodes the query component of a request target as described in
+// RFC 3986, so percent-encoded octets are decoded and "+" stands for itself.
+// An invalid percent-encoding is reported after the rest of the query has
+// been decoded.
+func ParseQuery(query string) (Query, error) {
+	return parseQuery(query, url.PathUnescape)
+}
+
+// ParseForm decodes data in the application/x-www-form-urlencoded format,
+// where "+" stands for a space. Errors are reported like by ParseQuery.
+func ParseForm(data string) (Query, error) {
+	return parseQuery(data, url.QueryUnescape)
+}
+
+func parseQuery(query string, unescape func(string) (string, error)) (Query, error) {
+	values := Query{}
+
+	var firstErr error
+	for _, pair := range strings.Split(query, "&") {
+		if pair == "" {
+			continue
+		}
+
+		rawKey, rawValue, _ := strings.Cut(pair, "=")
+		key, err := unescape(rawKey)
+		if err == nil {
+			var value string
+			if value, err = unescape(rawValue); err == nil {
+				values.Add(key, value)
+				continue
+			}
+		}
+
+		if firstErr == nil {
+			firstErr = fmt.Errorf("invalid query parameter %q: %w", pair, err)
+		}
+	}
+
+	return values, firstErr
+}
+
+// Get returns the first value of key, or "" if there is none.
+func (q Query) Get(key string) string {
+	if values := q[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Has reports whether key is present, with or without a value.
+func (q Query) Has(key string) bool {
+	_, found := q[key]
+	return found
+}
+
+// Add appends value to the values of key.
+func (q Query) Add(key, value string) {
+	q[key] = append(q[key], value)
+}
+
+// String returns the first value of key, or def if key is absent.
+func (q Query) String(key string, def string) string {
+	if !q.Has(key) {
+		return def
+	}
+	return q.Get(key)
+}
+
+// Strings returns every value of key, or def if key is absent.
+func (q Query) Strings(key string, def []string) []string {
+	if !q.Has(key) {
+		return def
+	}
+	return q[key]
+}
+
+// Int returns the first value of key as a base 10 integer, or def if key is
+// absent. A value that is not an integer is reported as a *QueryError.
+func (q Query) Int(key string, def int) (int, error) {
+	if !q.Has(key) {
+		return def, nil
+	}
+
+	value := q.Get(key)
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def, &QueryError{Key: key, Value: value, Err: err}
+	}
+	return n, nil
+}
+
+// Bool returns the first value of key as a boolean, or def if key is absent.
+// A key without a value, as in "?verbose", is true. Other values are parsed
+// with strconv.ParseBool, and those it rejects are reported as a *QueryError.
+func (q Query) Bool(key string, def bool) (bool, error) {
+	if !q.Has(key) {
+		return def, nil
+	}
+
+	value := q.Get(key)
+	if value == "" {
+		return true, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, &QueryError{Key: key, Value: value, Err: err}
+	}
+	return b, nil
+}
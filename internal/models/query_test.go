@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	testingutil "http-server/internal/util/testing"
+	"testing"
+)
+
+type parseQueryTest struct {
+	testingutil.BasicTest
+	query string
+	form  bool
+}
+
+func (test parseQueryTest) String() string {
+	return test.Description
+}
+
+func TestParseQuery(t *testing.T) {
+	const TEST_FUNCTION = "ParseQuery"
+
+	tests := []parseQueryTest{
+		{testingutil.BasicTest{Description: "Empty query", Want: "map[]"}, "", false},
+		{testingutil.BasicTest{Description: "Percent-encoded value", Want: "map[name:[a b]]"}, "name=a%20b", false},
+		{testingutil.BasicTest{Description: "Repeated key", Want: "map[name:[a b] tag:[x y]]"}, "name=a%20b&tag=x&tag=y", false},
+		{testingutil.BasicTest{Description: "Key without value", Want: "map[verbose:[]]"}, "verbose", false},
+		{testingutil.BasicTest{Description: "Empty pairs are skipped", Want: "map[a:[1]]"}, "&&a=1&", false},
+		{testingutil.BasicTest{Description: "Encoded key and separators", Want: "map[a&b:[c=d]]"}, "a%26b=c%3Dd", false},
+		{testingutil.BasicTest{Description: "Plus is literal", Want: "map[q:[a+b]]"}, "q=a+b", false},
+		{testingutil.BasicTest{Description: "Plus is a space in form mode", Want: "map[q:[a b]]"}, "q=a+b", true},
+		{testingutil.BasicTest{Description: "UTF-8", Want: "map[city:[Zürich]]"}, "city=Z%C3%BCrich", false},
+		{
+			testingutil.BasicTest{
+				Description: "Invalid escape",
+				Want:        "map[b:[2]]",
+				Error:       `invalid query parameter "a=%zz": invalid URL escape "%zz"`,
+			},
+			"a=%zz&b=2", false,
+		},
+	}
+
+	executeTest := func(t *testing.T, tt parseQueryTest) string {
+		parse := ParseQuery
+		if tt.form {
+			parse = ParseForm
+		}
+
+		query, err := parse(tt.query)
+		if tt.Error == "" && err != nil {
+			t.Errorf("%s(%q) returned '%s'", TEST_FUNCTION, tt.query, err)
+		} else if tt.Error != "" && err == nil {
+			t.Errorf("%s(%q) returned no error, want: '%s'", TEST_FUNCTION, tt.query, tt.Error)
+		}
+		testingutil.ValidateError(t, TEST_FUNCTION, err, tt.Error)
+
+		return fmt.Sprint(query)
+	}
+
+	validateTest := func(t *testing.T, tt parseQueryTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%q) = %s, want: %s", TEST_FUNCTION, tt.query, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+type queryAccessorTest struct {
+	testingutil.BasicTest
+	get func(Query) (any, error)
+}
+
+func (test queryAccessorTest) String() string {
+	return test.Description
+}
+
+func TestQueryAccessors(t *testing.T) {
+	query, _ := ParseQuery("id=42&bad=4x&on=true&flag&off=0&tag=x&tag=y")
+
+	tests := []queryAccessorTest{
+		{
+			testingutil.BasicTest{Description: "Int", Want: "42"},
+			func(q Query) (any, error) { return q.Int("id", 7) },
+		},
+		{
+			testingutil.BasicTest{Description: "Int default", Want: "7"},
+			func(q Query) (any, error) { return q.Int("missing", 7) },
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Int invalid",
+				Want:        "7",
+				Error:       `invalid value "4x" for query parameter "bad": strconv.Atoi: parsing "4x": invalid syntax`,
+			},
+			func(q Query) (any, error) { return q.Int("bad", 7) },
+		},
+		{
+			testingutil.BasicTest{Description: "Bool", Want: "true"},
+			func(q Query) (any, error) { return q.Bool("on", false) },
+		},
+		{
+			testingutil.BasicTest{Description: "Bool without value", Want: "true"},
+			func(q Query) (any, error) { return q.Bool("flag", false) },
+		},
+		{
+			testingutil.BasicTest{Description: "Bool false", Want: "false"},
+			func(q Query) (any, error) { return q.Bool("off", true) },
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Bool invalid",
+				Want:        "true",
+				Error:       `invalid value "42" for query parameter "id": strconv.ParseBool: parsing "42": invalid syntax`,
+			},
+			func(q Query) (any, error) { return q.Bool("id", true) },
+		},
+		{
+			testingutil.BasicTest{Description: "Strings", Want: "[x y]"},
+			func(q Query) (any, error) { return q.Strings("tag", nil), nil },
+		},
+		{
+			testingutil.BasicTest{Description: "Strings default", Want: "[z]"},
+			func(q Query) (any, error) { return q.Strings("missing", []string{"z"}), nil },
+		},
+		{
+			testingutil.BasicTest{Description: "String", Want: "x"},
+			func(q Query) (any, error) { return q.String("tag", "z"), nil },
+		},
+		{
+			testingutil.BasicTest{Description: "String of key without value", Want: ""},
+			func(q Query) (any, error) { return q.String("flag", "z"), nil },
+		},
+	}
+
+	executeTest := func(t *testing.T, tt queryAccessorTest) string {
+		got, err := tt.get(query)
+		if tt.Error == "" && err != nil {
+			t.Errorf("%s returned '%s'", tt.Description, err)
+		} else if tt.Error != "" && err == nil {
+			t.Errorf("%s returned no error, want: '%s'", tt.Description, tt.Error)
+		}
+		testingutil.ValidateError(t, tt.Description, err, tt.Error)
+
+		return fmt.Sprint(got)
+	}
+
+	validateTest := func(t *testing.T, tt queryAccessorTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s = %s, want: %s", tt.Description, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
@@ -0,0 +1,292 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"http-server/internal/models"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrBodyReadAfterClose = errors.New("read on closed request body")
+
+// Body is the body of a request as it arrives on the connection, with the
+// framing of its Content-Length or chunked Transfer-Encoding removed.
+// Errors in the body are returned by Read: a *RequestError if the body is
+// malformed, too large or too slow to arrive, and io.ErrUnexpectedEOF if the
+// client hangs up before its end. Errors are sticky, since the framing of
+// the connection cannot be trusted after one.
+type Body struct {
+	src  io.Reader
+	conn interface{ SetReadDeadline(time.Time) error }
+
+	mu     sync.Mutex
+	eof    bool
+	closed bool
+	err    error
+	onEOF  func()
+}
+
+// emptyBody returns a Body that is already at its end.
+func emptyBody() *Body {
+	return &Body{eof: true, err: io.EOF}
+}
+
+func (b *Body) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	closed, err := b.closed, b.err
+	b.mu.Unlock()
+
+	if closed {
+		return 0, ErrBodyReadAfterClose
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return b.read(p)
+}
+
+func (b *Body) read(p []byte) (int, error) {
+	n, err := b.src.Read(p)
+	if err == nil {
+		return n, nil
+	}
+
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		err = &RequestError{Status: STATUS_REQUEST_TIMEOUT, Err: ErrRequestTimeout}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.err = err
+	if err == io.EOF {
+		b.eof = true
+		if b.onEOF != nil {
+			b.onEOF()
+			b.onEOF = nil
+		}
+	}
+
+	return n, err
+}
+
+// Close makes further reads fail. The rest of the body stays on the
+// connection, where the server discards it.
+func (b *Body) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	return nil
+}
+
+// SetConn makes SetReadDeadline apply to conn, the connection the body is
+// read from.
+func (b *Body) SetConn(conn interface{ SetReadDeadline(time.Time) error }) {
+	b.conn = conn
+}
+
+// SetReadDeadline sets the time by which the rest of the body must have
+// arrived. Reads that pass it fail with a *RequestError with
+// STATUS_REQUEST_TIMEOUT. It has no effect once the body has been read.
+func (b *Body) SetReadDeadline(deadline time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.eof || b.err != nil {
+		return nil
+	}
+	if b.conn == nil {
+		return ErrDeadlineNotSupported
+	}
+
+	return b.conn.SetReadDeadline(deadline)
+}
+
+// OnEOF arranges for f to be called once the body has been read to its end,
+// or right away if it already has.
+func (b *Body) OnEOF(f func()) {
+	b.mu.Lock()
+	if !b.eof {
+		b.onEOF = f
+		b.mu.Unlock()
+		return
+	}
+	b.mu.Unlock()
+
+	f()
+}
+
+// Discard reads and drops the rest of the body, up to max bytes, whether or
+// not it has been closed. It reports whether the end of the body was reached,
+// which means the connection can be used for another request. The function
+// given to OnEOF is not called.
+func (b *Body) Discard(max int64) bool {
+	b.mu.Lock()
+	b.onEOF = nil
+	eof, err := b.eof, b.err
+	b.mu.Unlock()
+
+	if eof {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+
+	io.CopyN(io.Discard, readerFunc(b.read), max)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.eof
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
+// lengthReader reads a body framed by its Content-Length.
+type lengthReader struct {
+	reader    *bufio.Reader
+	remaining int64
+}
+
+func (r *lengthReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	if err == io.EOF && r.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	if err == nil && r.remaining == 0 {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// chunkedReader decodes a chunked body. Chunk extensions are ignored.
+// Trailer fields are added to trailers once the last chunk has been read,
+// leaving out fields that are not allowed in a trailer.
+type chunkedReader struct {
+	reader    *bufio.Reader
+	limits    Limits
+	trailers  models.Header
+	remaining int64
+	read      int64
+	done      bool
+}
+
+func (r *chunkedReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, io.EOF
+	}
+
+	if r.remaining == 0 {
+		if err := r.nextChunk(); err != nil {
+			return 0, err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	r.read += int64(n)
+	if err != nil {
+		return n, unexpectedEOF(err)
+	}
+
+	if r.remaining == 0 {
+		if line, err := readLine(r.reader, len(CRLF)); err == errLineTooLong || (err == nil && line != "") {
+			return n, badRequest(ErrMissingChunkTerminator)
+		} else if err != nil {
+			return n, unexpectedEOF(err)
+		}
+	}
+
+	return n, nil
+}
+
+// nextChunk reads the size line of the next chunk, and the trailer fields if
+// it is the last one.
+func (r *chunkedReader) nextChunk() error {
+	line, err := readChunkLine(r.reader, maxChunkLineBytes)
+	if err != nil {
+		return err
+	}
+
+	size, err := parseChunkSize(line)
+	if err != nil {
+		return badRequest(err)
+	}
+
+	if size == 0 {
+		r.done = true
+		return r.readTrailers()
+	}
+
+	if size > r.limits.MaxBodyBytes-r.read {
+		return bodyTooLarge()
+	}
+
+	r.remaining = size
+	return nil
+}
+
+func (r *chunkedReader) readTrailers() error {
+	lines := []string{}
+	trailerBytes := 0
+	for {
+		line, err := readLine(r.reader, r.limits.MaxHeaderBytes-trailerBytes+len(CRLF))
+		if err == errLineTooLong {
+			return headerTooLarge(ErrHeaderTooLarge)
+		}
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		if line == "" {
+			break
+		}
+
+		trailerBytes += len(line) + len(CRLF)
+		if len(lines) == r.limits.MaxHeaderCount {
+			return headerTooLarge(ErrTooManyHeaders)
+		}
+
+		lines = append(lines, line)
+	}
+
+	trailers, err := parseHeaders(lines)
+	if err != nil {
+		return badRequest(ErrInvalidTrailer)
+	}
+
+	for name, values := range trailers {
+		if !trailerBlacklist[strings.ToLower(name)] {
+			r.trailers[name] = values
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	testingutil "http-server/internal/util/testing"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+type bodyTest struct {
+	testingutil.BasicTest
+	request string
+	read    int
+}
+
+func (test bodyTest) String() string {
+	return test.Description
+}
+
+const maxDiscardTestBytes = 1 << 10
+
+// readBodyPrefix reads the head of request and then read bytes of its body,
+// closes the body and discards the rest. It renders what was read, whether
+// the body could be discarded and what the reader holds after it.
+func readBodyPrefix(t *testing.T, request string, read int) (string, error) {
+	reader := writeChunks(t, []string{request}, 0)
+
+	head, err := ReadRequestHead(reader, DefaultLimits)
+	if err != nil {
+		return "", err
+	}
+	if err := ReadRequestBody(reader, &head, DefaultLimits); err != nil {
+		return "", err
+	}
+
+	body := head.Body.(*Body)
+	prefix := make([]byte, read)
+	n, err := io.ReadFull(body, prefix)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	body.Close()
+	if _, err := body.Read(prefix); err != ErrBodyReadAfterClose {
+		return "", fmt.Errorf("Read after Close returned '%v'", err)
+	}
+
+	discarded := body.Discard(maxDiscardTestBytes)
+	rest, _ := reader.Peek(reader.Buffered())
+	return fmt.Sprintf("%s %t %s trailers=%v", prefix[:n], discarded, rest, head.Trailers), nil
+}
+
+func TestBodyDiscard(t *testing.T) {
+	const TEST_FUNCTION = "Body.Discard"
+
+	tests := []bodyTest{
+		{
+			testingutil.BasicTest{Description: "Content-Length body read in part", Want: "{\"username\" true GET /next trailers=map[]"},
+			POST_REQUEST_HEAD + CRLF + POST_REQUEST_BODY + "GET /next", 11,
+		},
+		{
+			testingutil.BasicTest{Description: "Chunked body read in part", Want: "{\"username\" true GET /next trailers=map[X-Sum:[1]]"},
+			CHUNKED_REQUEST_HEAD + CRLF + CHUNKED_REQUEST_BODY + "X-Sum: 1\r\nContent-Length: 5\r\n\r\nGET /next", 11,
+		},
+		{
+			testingutil.BasicTest{Description: "Body without framing", Want: " true GET /next trailers=map[]"},
+			GET_REQUEST_HEAD + CRLF + "GET /next", 4,
+		},
+		{
+			testingutil.BasicTest{Description: "Body too large to discard", Want: "ab false  trailers=map[]"},
+			fmt.Sprintf("POST / HTTP/1.1\r\nContent-Length: %d\r\n\r\nab", 2*maxDiscardTestBytes), 2,
+		},
+	}
+
+	executeTest := func(t *testing.T, tt bodyTest) string {
+		got, err := readBodyPrefix(t, tt.request, tt.read)
+		if err != nil {
+			return err.Error()
+		}
+		return got
+	}
+
+	validateTest := func(t *testing.T, tt bodyTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%.60q) = %q, want: %q", TEST_FUNCTION, tt.request, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestBodyStreams(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go client.Write([]byte(CHUNKED_REQUEST_HEAD + CRLF + "5\r\nhello\r\n"))
+
+	reader := bufio.NewReader(server)
+	request, err := ReadRequestHead(reader, DefaultLimits)
+	if err != nil {
+		t.Fatalf("ReadRequestHead() returned error '%s'", err)
+	}
+	if err := ReadRequestBody(reader, &request, DefaultLimits); err != nil {
+		t.Fatalf("ReadRequestBody() returned error '%s'", err)
+	}
+
+	// The first chunk can be read while the client has not sent the rest.
+	chunk := make([]byte, 5)
+	if _, err := io.ReadFull(request.Body, chunk); err != nil || string(chunk) != "hello" {
+		t.Fatalf("Body.Read() = %q, '%v', want: %q", chunk, err, "hello")
+	}
+
+	eof := make(chan struct{})
+	body := request.Body.(*Body)
+	body.SetConn(server)
+	body.OnEOF(func() { close(eof) })
+
+	go client.Write([]byte("0\r\n\r\n"))
+	if _, err := body.Read(chunk); err != io.EOF {
+		t.Errorf("Body.Read() at the end returned '%v', want: '%s'", err, io.EOF)
+	}
+
+	select {
+	case <-eof:
+	case <-time.After(time.Second):
+		t.Errorf("Body.OnEOF() function was not called at the end of the body")
+	}
+}
+
+func TestBodyReadDeadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	go client.Write([]byte("POST / HTTP/1.1\r\nContent-Length: 10\r\n\r\nabc"))
+
+	reader := bufio.NewReader(server)
+	request, err := ReadRequestHead(reader, DefaultLimits)
+	if err != nil {
+		t.Fatalf("ReadRequestHead() returned error '%s'", err)
+	}
+	if err := ReadRequestBody(reader, &request, DefaultLimits); err != nil {
+		t.Fatalf("ReadRequestBody() returned error '%s'", err)
+	}
+
+	body := request.Body.(*Body)
+	body.SetConn(server)
+	body.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+
+	_, err = request.ReadBody()
+	requestErr := (*RequestError)(nil)
+	if !errors.As(err, &requestErr) || requestErr.Status != STATUS_REQUEST_TIMEOUT {
+		t.Fatalf("ReadBody() returned '%v', want a *RequestError with status %d", err, STATUS_REQUEST_TIMEOUT)
+	}
+
+	// Errors are sticky.
+	if _, err := body.Read(make([]byte, 1)); err != requestErr {
+		t.Errorf("Body.Read() after the timeout returned '%v', want: '%s'", err, requestErr)
+	}
+}
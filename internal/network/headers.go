@@ -0,0 +1,118 @@
+package network
+
+import (
+	"errors"
+	"http-server/internal/models"
+	"net/textproto"
+	"strings"
+)
+
+var (
+	ErrMalformedHeader    = errors.New("malformed header line")
+	ErrInvalidHeaderName  = errors.New("invalid header field name")
+	ErrInvalidHeaderValue = errors.New("invalid header field value")
+)
+
+// parseHeaders parses header lines, without their line terminators, into a
+// Header. Repeated fields are kept as separate values in the order they were
+// received, and lines folded with leading whitespace (obs-fold) are joined to
+// the previous field with a single space.
+func parseHeaders(lines []string) (models.Header, error) {
+	header := models.Header{}
+
+	var name string
+	for _, line := range lines {
+		if line[0] == ' ' || line[0] == '\t' {
+			if name == "" {
+				return nil, badRequest(ErrMalformedHeader)
+			}
+
+			continuation := trimOWS(line)
+			if !isFieldValue(continuation) {
+				return nil, badRequest(ErrInvalidHeaderValue)
+			}
+
+			if values := header[name]; continuation != "" {
+				values[len(values)-1] = strings.TrimLeft(values[len(values)-1]+" "+continuation, " ")
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			return nil, badRequest(ErrMalformedHeader)
+		}
+
+		// Whitespace between the field name and colon is forbidden because
+		// intermediaries disagree on how to treat it.
+		if !isToken(key) {
+			return nil, badRequest(ErrInvalidHeaderName)
+		}
+
+		value = trimOWS(value)
+		if !isFieldValue(value) {
+			return nil, badRequest(ErrInvalidHeaderValue)
+		}
+
+		name = textproto.CanonicalMIMEHeaderKey(key)
+		header[name] = append(header[name], value)
+	}
+
+	return header, nil
+}
+
+// headerTokens splits the comma-separated list values of the named field
+// into their elements, dropping empty ones.
+func headerTokens(header models.Header, name string) []string {
+	tokens := []string{}
+	for _, value := range header.Values(name) {
+		for _, token := range strings.Split(value, ",") {
+			if token = trimOWS(token); token != "" {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	return tokens
+}
+
+// hasToken reports whether the named list field contains token, compared
+// case-insensitively.
+func hasToken(header models.Header, name, token string) bool {
+	for _, t := range headerTokens(header, name) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func trimOWS(value string) string {
+	return strings.Trim(value, " \t")
+}
+
+// isToken reports whether value is a non-empty token as defined by RFC 9110,
+// which is the syntax of methods and header field names.
+func isToken(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; !isAlpha(c) && !isDigit(c) && strings.IndexByte("!#$%&'*+-.^_`|~", c) == -1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isFieldValue reports whether value contains no control characters other
+// than horizontal tab.
+func isFieldValue(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; (c < ' ' && c != '\t') || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
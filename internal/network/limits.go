@@ -0,0 +1,55 @@
+package network
+
+import "errors"
+
+// Limits bounds how much of a request the server is willing to read.
+// Zero fields fall back to the value in DefaultLimits.
+type Limits struct {
+	// MaxHeaderBytes bounds the size of the header lines, including their
+	// line terminators, and separately that of the trailer lines.
+	MaxHeaderBytes int
+
+	// MaxHeaderCount bounds the number of header lines.
+	MaxHeaderCount int
+
+	// MaxBodyBytes bounds the size of the decoded body.
+	MaxBodyBytes int64
+}
+
+var DefaultLimits = Limits{
+	MaxHeaderBytes: 64 << 10,
+	MaxHeaderCount: 100,
+	MaxBodyBytes:   10 << 20,
+}
+
+// maxChunkLineBytes bounds a chunk size line with its extensions.
+const maxChunkLineBytes = 4096
+
+var (
+	ErrHeaderTooLarge   = errors.New("request header fields too large")
+	ErrTooManyHeaders   = errors.New("too many request header fields")
+	ErrBodyTooLarge     = errors.New("request body too large")
+	ErrChunkLineTooLong = errors.New("chunk size line too long")
+	errLineTooLong      = errors.New("line too long")
+)
+
+func (l Limits) withDefaults() Limits {
+	if l.MaxHeaderBytes <= 0 {
+		l.MaxHeaderBytes = DefaultLimits.MaxHeaderBytes
+	}
+	if l.MaxHeaderCount <= 0 {
+		l.MaxHeaderCount = DefaultLimits.MaxHeaderCount
+	}
+	if l.MaxBodyBytes <= 0 {
+		l.MaxBodyBytes = DefaultLimits.MaxBodyBytes
+	}
+	return l
+}
+
+func headerTooLarge(err error) *RequestError {
+	return &RequestError{Status: STATUS_REQUEST_HEADER_FIELDS_TOO_LARGE, Err: err}
+}
+
+func bodyTooLarge() *RequestError {
+	return &RequestError{Status: STATUS_CONTENT_TOO_LARGE, Err: ErrBodyTooLarge}
+}
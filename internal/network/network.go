@@ -1,41 +1,26 @@
 package network
 
 import (
-	"fmt"
+	"io"
 	"net"
 	"time"
 )
 
-func SendData(data string, conn net.Conn) {
-	_, err := conn.Write([]byte(data))
-	if err != nil {
-		fmt.Println("Error:", err)
-		return
-	}
-}
-
-func GetData(conn net.Conn) string {
-	buffer := make([]byte, 1024)
-	var data string
-	for {
-		//Set timeout
-		conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
-		n, err := conn.Read(buffer)
-
-		//Handle time out
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			fmt.Println("read timeout:", err)
-			break
-		}
+const (
+	lingerTimeout  = 500 * time.Millisecond
+	maxLingerBytes = 256 << 10
+)
 
-		//Handle error
-		if err != nil {
-			fmt.Printf("Cannot read data into buffer, %v\n", err)
-			return ""
-		}
+// CloseAfterError closes conn after answering a request that was rejected
+// before it was read completely. Closing a socket that still has unread input
+// makes the kernel reset the connection, which can destroy the response before
+// the client reads it, so the write side is shut down first and whatever the
+// client is still sending is discarded for a moment.
+func CloseAfterError(conn net.Conn) {
+	defer conn.Close()
 
-		data += string(buffer[:n])
+	if tcpConn, ok := conn.(interface{ CloseWrite() error }); ok && tcpConn.CloseWrite() == nil {
+		conn.SetReadDeadline(time.Now().Add(lingerTimeout))
+		io.CopyN(io.Discard, conn, maxLingerBytes)
 	}
-
-	return data
 }
@@ -0,0 +1,257 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"http-server/internal/models"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidContentLength        = errors.New("invalid Content-Length header")
+	ErrMultipleContentLengths      = errors.New("conflicting Content-Length headers")
+	ErrContentLengthAndChunked     = errors.New("both Content-Length and Transfer-Encoding headers are present")
+	ErrUnsupportedTransferEncoding = errors.New("unsupported Transfer-Encoding")
+	ErrInvalidChunkSize            = errors.New("invalid chunk size")
+	ErrMissingChunkTerminator      = errors.New("chunk data is not followed by CRLF")
+	ErrInvalidTrailer              = errors.New("invalid trailer field")
+)
+
+// maxChunkSizeHexDigits keeps chunk sizes within an int64.
+const maxChunkSizeHexDigits = 15
+
+// RequestError reports a request that violates the protocol.
+// Status is the status code the server should answer with before it closes
+// the connection.
+type RequestError struct {
+	Status int
+	Err    error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+func badRequest(err error) *RequestError {
+	return &RequestError{Status: STATUS_BAD_REQUEST, Err: err}
+}
+
+// trailerBlacklist holds the fields a sender may not put in a trailer
+// because they are needed before the body can be processed.
+var trailerBlacklist = map[string]bool{
+	"content-length":    true,
+	"transfer-encoding": true,
+	"trailer":           true,
+	"host":              true,
+	"content-type":      true,
+	"content-encoding":  true,
+}
+
+// readHead reads the request line and header lines up to the blank line that
+// terminates them, and returns them without their line terminators.
+// It returns io.EOF if the peer closed the connection before sending anything.
+func readHead(reader *bufio.Reader, limits Limits) ([]string, error) {
+	lines := []string{}
+	headerBytes := 0
+
+	for {
+		// The request line is bounded by the longest target it may hold, plus
+		// room for the method and version.
+		limit := MAX_URI_LENGTH + 64
+		if len(lines) > 0 {
+			limit = limits.MaxHeaderBytes - headerBytes + len(CRLF)
+		}
+
+		line, err := readLine(reader, limit)
+		if err != nil {
+			switch {
+			case err == errLineTooLong && len(lines) == 0:
+				return nil, &RequestError{Status: STATUS_URI_TOO_LONG, Err: ErrURITooLong}
+			case err == errLineTooLong:
+				return nil, headerTooLarge(ErrHeaderTooLarge)
+			case len(lines) > 0:
+				return nil, unexpectedEOF(err)
+			}
+			return nil, err
+		}
+
+		if line == "" {
+			// Servers should ignore empty lines received before the request line.
+			if len(lines) == 0 {
+				continue
+			}
+			return lines, nil
+		}
+
+		if len(lines) > 0 {
+			headerBytes += len(line) + len(CRLF)
+			if len(lines) > limits.MaxHeaderCount {
+				return nil, headerTooLarge(ErrTooManyHeaders)
+			}
+		}
+
+		lines = append(lines, line)
+	}
+}
+
+// readLine reads a line of at most limit bytes, including its terminator,
+// and returns it without the terminator. Longer lines fail with errLineTooLong
+// before they are read completely.
+func readLine(reader *bufio.Reader, limit int) (string, error) {
+	var line []byte
+
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if len(line)+len(chunk) > limit {
+			return "", errLineTooLong
+		}
+		line = append(line, chunk...)
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+
+		return strings.TrimRight(string(line), "\r\n"), nil
+	}
+}
+
+// readBody returns the body that follows a head with the given header, as
+// framed by its Content-Length or Transfer-Encoding field, and the trailer
+// fields of a chunked body, which are filled in once it has been read.
+// Invalid framing fields and a Content-Length above the limit are reported at
+// once, other errors by the Read method of the body.
+// Nothing beyond the body is consumed, so the next pipelined request stays
+// buffered in reader.
+func readBody(reader *bufio.Reader, header models.Header, limits Limits) (*Body, models.Header, error) {
+	contentLength := int64(-1)
+	if header.Has("Content-Length") && len(headerTokens(header, "Content-Length")) == 0 {
+		return nil, nil, badRequest(ErrInvalidContentLength)
+	}
+
+	for _, value := range headerTokens(header, "Content-Length") {
+		length, err := parseContentLength(value)
+		if err != nil {
+			return nil, nil, badRequest(err)
+		}
+		if contentLength != -1 && contentLength != length {
+			return nil, nil, badRequest(ErrMultipleContentLengths)
+		}
+		contentLength = length
+	}
+
+	if header.Has("Transfer-Encoding") {
+		// A message with both headers is how request smuggling attacks disagree
+		// with intermediaries about where the message ends, so refuse it.
+		if header.Has("Content-Length") {
+			return nil, nil, badRequest(ErrContentLengthAndChunked)
+		}
+
+		if err := checkTransferEncoding(headerTokens(header, "Transfer-Encoding")); err != nil {
+			return nil, nil, err
+		}
+
+		trailers := models.Header{}
+		return &Body{src: &chunkedReader{reader: reader, limits: limits, trailers: trailers}}, trailers, nil
+	}
+
+	if contentLength <= 0 {
+		return emptyBody(), nil, nil
+	}
+
+	if contentLength > limits.MaxBodyBytes {
+		return nil, nil, bodyTooLarge()
+	}
+
+	return &Body{src: &lengthReader{reader: reader, remaining: contentLength}}, nil, nil
+}
+
+func parseContentLength(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, ErrInvalidContentLength
+	}
+
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return 0, ErrInvalidContentLength
+		}
+	}
+
+	length, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidContentLength
+	}
+
+	return length, nil
+}
+
+// checkTransferEncoding accepts "chunked" as the only transfer coding.
+// A request whose final coding is not chunked cannot be framed at all and is
+// a bad request, while other codings applied before chunked are valid but not
+// implemented by this server.
+func checkTransferEncoding(codings []string) error {
+	if len(codings) == 0 {
+		return badRequest(ErrUnsupportedTransferEncoding)
+	}
+
+	for i, coding := range codings {
+		if last := i == len(codings)-1; strings.EqualFold(coding, "chunked") != last {
+			return badRequest(ErrUnsupportedTransferEncoding)
+		}
+	}
+
+	if len(codings) != 1 {
+		return &RequestError{Status: STATUS_NOT_IMPLEMENTED, Err: ErrUnsupportedTransferEncoding}
+	}
+
+	return nil
+}
+
+func readChunkLine(reader *bufio.Reader, limit int) (string, error) {
+	line, err := readLine(reader, limit)
+	if err == errLineTooLong {
+		return "", badRequest(ErrChunkLineTooLong)
+	}
+
+	return line, unexpectedEOF(err)
+}
+
+// unexpectedEOF turns io.EOF into io.ErrUnexpectedEOF for reads that happen
+// in the middle of a message.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// parseChunkSize parses the hexadecimal size at the start of a chunk line,
+// skipping any chunk extensions that follow it.
+func parseChunkSize(line string) (int64, error) {
+	size, _, _ := strings.Cut(line, ";")
+	size = strings.TrimRight(size, " \t")
+
+	if size == "" || len(size) > maxChunkSizeHexDigits {
+		return 0, ErrInvalidChunkSize
+	}
+
+	for i := 0; i < len(size); i++ {
+		if !isHex(size[i]) {
+			return 0, ErrInvalidChunkSize
+		}
+	}
+
+	return strconv.ParseInt(size, 16, 64)
+}
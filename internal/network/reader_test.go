@@ -0,0 +1,419 @@
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"http-server/internal/models"
+	testingutil "http-server/internal/util/testing"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type message struct {
+	head string
+	body string
+}
+
+type readRequestTest struct {
+	testingutil.BasicTest
+	chunks []string
+	delay  time.Duration
+}
+
+func (test readRequestTest) String() string {
+	return test.Description
+}
+
+const (
+	GET_REQUEST_HEAD  = "GET /hello HTTP/1.1\r\nHost: localhost\r\n"
+	POST_REQUEST_HEAD = "POST /users/create HTTP/1.1\r\nContent-Length: 41\r\nHost: localhost\r\n"
+	POST_REQUEST_BODY = `{"username":"daniel","password":"123456"}`
+
+	CHUNKED_REQUEST_HEAD = "POST /users/create HTTP/1.1\r\nHost: localhost\r\nTransfer-Encoding: chunked\r\n"
+	CHUNKED_REQUEST_BODY = "15\r\n{\"username\":\"daniel\",\r\n" +
+		"14;name=value\r\n\"password\":\"123456\"}\r\n" +
+		"0\r\n"
+)
+
+// readMessage reads a request with ReadRequest and renders its head with the
+// headers in sorted order, followed by any trailers.
+func readMessage(reader *bufio.Reader) (message, error) {
+	request, err := ReadRequest(reader, DefaultLimits)
+	if err != nil {
+		return message{}, err
+	}
+
+	head := request.Method + " " + request.Path + " " + request.Version + CRLF
+	for _, header := range []models.Header{request.Headers, request.Trailers} {
+		names := make([]string, 0, len(header))
+		for name := range header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, value := range header[name] {
+				head += name + ": " + value + CRLF
+			}
+		}
+	}
+
+	body, err := request.ReadBodyString()
+	return message{head, body}, err
+}
+
+// writeChunks writes each chunk to one end of a pipe, waiting delay between
+// chunks, and returns a reader for the other end.
+func writeChunks(t *testing.T, chunks []string, delay time.Duration) *bufio.Reader {
+	client, server := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+
+	go func() {
+		defer client.Close()
+		for i, chunk := range chunks {
+			if i > 0 {
+				time.Sleep(delay)
+			}
+			if _, err := client.Write([]byte(chunk)); err != nil {
+				return
+			}
+		}
+	}()
+
+	return bufio.NewReader(server)
+}
+
+// splitEvery splits value into chunks of at most n bytes.
+func splitEvery(value string, n int) []string {
+	chunks := []string{}
+	for len(value) > n {
+		chunks = append(chunks, value[:n])
+		value = value[n:]
+	}
+	return append(chunks, value)
+}
+
+func TestReadRequest(t *testing.T) {
+	const TEST_FUNCTION = "ReadRequest"
+
+	tests := []readRequestTest{
+		{
+			testingutil.BasicTest{
+				Description: "Request without body in a single write",
+				Want:        message{GET_REQUEST_HEAD, ""},
+			},
+			[]string{GET_REQUEST_HEAD + CRLF},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Request with body in a single write",
+				Want:        message{POST_REQUEST_HEAD, POST_REQUEST_BODY},
+			},
+			[]string{POST_REQUEST_HEAD + CRLF + POST_REQUEST_BODY},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Request fragmented into three byte writes",
+				Want:        message{POST_REQUEST_HEAD, POST_REQUEST_BODY},
+			},
+			splitEvery(POST_REQUEST_HEAD+CRLF+POST_REQUEST_BODY, 3),
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Head and body split at the blank line",
+				Want:        message{POST_REQUEST_HEAD, POST_REQUEST_BODY},
+			},
+			[]string{POST_REQUEST_HEAD + "\r", "\n", POST_REQUEST_BODY},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Slow upload with pauses longer than the old read timeout",
+				Want:        message{POST_REQUEST_HEAD, POST_REQUEST_BODY},
+			},
+			[]string{POST_REQUEST_HEAD + CRLF, POST_REQUEST_BODY[:10], POST_REQUEST_BODY[10:]},
+			400 * time.Millisecond,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Leading empty lines are ignored",
+				Want:        message{GET_REQUEST_HEAD, ""},
+			},
+			[]string{CRLF + CRLF + GET_REQUEST_HEAD + CRLF},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Connection closed before anything was sent",
+				Want:        message{},
+				Error:       io.EOF.Error(),
+			},
+			[]string{},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Connection closed in the middle of the body",
+				Want:        message{},
+				Error:       io.ErrUnexpectedEOF.Error(),
+			},
+			[]string{POST_REQUEST_HEAD + CRLF + POST_REQUEST_BODY[:10]},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Invalid Content-Length",
+				Want:        message{},
+				Error:       ErrInvalidContentLength.Error(),
+			},
+			[]string{"POST / HTTP/1.1\r\nContent-Length: -1\r\n\r\n"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Conflicting Content-Length headers",
+				Want:        message{},
+				Error:       ErrMultipleContentLengths.Error(),
+			},
+			[]string{"POST / HTTP/1.1\r\nContent-Length: 1\r\nContent-Length: 2\r\n\r\nab"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Chunked body with chunk extensions",
+				Want:        message{CHUNKED_REQUEST_HEAD, POST_REQUEST_BODY},
+			},
+			[]string{CHUNKED_REQUEST_HEAD + CRLF + CHUNKED_REQUEST_BODY + CRLF},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Chunked body fragmented into three byte writes",
+				Want:        message{CHUNKED_REQUEST_HEAD, POST_REQUEST_BODY},
+			},
+			splitEvery(CHUNKED_REQUEST_HEAD+CRLF+CHUNKED_REQUEST_BODY+CRLF, 3),
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Chunked body with trailers",
+				Want:        message{CHUNKED_REQUEST_HEAD + "Checksum: abc\r\n", POST_REQUEST_BODY},
+			},
+			[]string{CHUNKED_REQUEST_HEAD + CRLF + CHUNKED_REQUEST_BODY + "Checksum: abc\r\nContent-Length: 5\r\n" + CRLF},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Both Content-Length and Transfer-Encoding",
+				Want:        message{},
+				Error:       ErrContentLengthAndChunked.Error(),
+			},
+			[]string{CHUNKED_REQUEST_HEAD + "Content-Length: 41\r\n" + CRLF + CHUNKED_REQUEST_BODY + CRLF},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Transfer-Encoding that does not end in chunked",
+				Want:        message{},
+				Error:       ErrUnsupportedTransferEncoding.Error(),
+			},
+			[]string{"POST / HTTP/1.1\r\nTransfer-Encoding: chunked, gzip\r\n\r\n"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Invalid chunk size",
+				Want:        message{},
+				Error:       ErrInvalidChunkSize.Error(),
+			},
+			[]string{CHUNKED_REQUEST_HEAD + CRLF + "1x\r\na\r\n0\r\n\r\n"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Chunk data longer than its size",
+				Want:        message{},
+				Error:       ErrMissingChunkTerminator.Error(),
+			},
+			[]string{CHUNKED_REQUEST_HEAD + CRLF + "1\r\nab\r\n0\r\n\r\n"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Header names are canonicalized",
+				Want:        message{"GET / HTTP/1.1\r\nContent-Type: text/plain\r\nX-Request-Id: 1\r\n", ""},
+			},
+			[]string{"GET / HTTP/1.1\r\ncontent-TYPE:text/plain\r\nx-request-id: \t1 \r\n\r\n"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Duplicate headers keep every value in order",
+				Want:        message{"GET / HTTP/1.1\r\nAccept: text/html\r\nAccept: application/json\r\n", ""},
+			},
+			[]string{"GET / HTTP/1.1\r\nAccept: text/html\r\naccept: application/json\r\n\r\n"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Folded header lines are joined with a space",
+				Want:        message{"GET / HTTP/1.1\r\nX-Long: first second third\r\n", ""},
+			},
+			[]string{"GET / HTTP/1.1\r\nX-Long: first\r\n  second\r\n\tthird\r\n\r\n"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Header line without a colon",
+				Want:        message{},
+				Error:       ErrMalformedHeader.Error(),
+			},
+			[]string{"GET / HTTP/1.1\r\nHost localhost\r\n\r\n"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Folded line before any header",
+				Want:        message{},
+				Error:       ErrMalformedHeader.Error(),
+			},
+			[]string{"GET / HTTP/1.1\r\n folded\r\n\r\n"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Whitespace between header name and colon",
+				Want:        message{},
+				Error:       ErrInvalidHeaderName.Error(),
+			},
+			[]string{"GET / HTTP/1.1\r\nContent-Length : 0\r\n\r\n"},
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Control character in header value",
+				Want:        message{},
+				Error:       ErrInvalidHeaderValue.Error(),
+			},
+			[]string{"GET / HTTP/1.1\r\nX-Value: a\x00b\r\n\r\n"},
+			0,
+		},
+	}
+
+	executeTest := func(t *testing.T, tt readRequestTest) message {
+		got, err := readMessage(writeChunks(t, tt.chunks, tt.delay))
+		if err == nil && tt.Error != "" {
+			t.Errorf("%s expected the error '%s' but got none", TEST_FUNCTION, tt.Error)
+		}
+		testingutil.ValidateError(t, TEST_FUNCTION, err, tt.Error)
+		return got
+	}
+
+	validateTest := func(t *testing.T, tt readRequestTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[message](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%q) = %q, want: %q", TEST_FUNCTION, tt.chunks, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestReadRequestPipelined(t *testing.T) {
+	reader := writeChunks(t, []string{
+		GET_REQUEST_HEAD + CRLF + POST_REQUEST_HEAD + CRLF + POST_REQUEST_BODY + GET_REQUEST_HEAD + CRLF,
+	}, 0)
+
+	wants := []message{
+		{GET_REQUEST_HEAD, ""},
+		{POST_REQUEST_HEAD, POST_REQUEST_BODY},
+		{GET_REQUEST_HEAD, ""},
+	}
+
+	for i, want := range wants {
+		got, err := readMessage(reader)
+		if err != nil {
+			t.Fatalf("ReadRequest() #%d returned error '%s'", i, err)
+		}
+		if got != want {
+			t.Errorf("ReadRequest() #%d = %q, want: %q", i, got, want)
+		}
+	}
+
+	if _, err := ReadRequest(reader, DefaultLimits); err != io.EOF {
+		t.Errorf("ReadRequest() after the last request returned '%v', want: '%s'", err, io.EOF)
+	}
+}
+
+func TestReadRequestDoesNotWaitForTimeout(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	// The client keeps the connection open, so the only way to finish is framing.
+	go client.Write([]byte(POST_REQUEST_HEAD + CRLF + POST_REQUEST_BODY))
+
+	start := time.Now()
+	if _, err := ReadRequest(bufio.NewReader(server), DefaultLimits); err != nil {
+		t.Fatalf("ReadRequest() returned error '%s'", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("ReadRequest() took %s, want it to return as soon as the body arrived", elapsed)
+	}
+}
+
+func TestReadRequestRequestErrorResponses(t *testing.T) {
+	small := Limits{MaxHeaderBytes: 64, MaxHeaderCount: 2, MaxBodyBytes: 16}
+
+	tests := []struct {
+		request string
+		limits  Limits
+		status  int
+	}{
+		{CHUNKED_REQUEST_HEAD + "Content-Length: 41\r\n" + CRLF, DefaultLimits, STATUS_BAD_REQUEST},
+		{"POST / HTTP/1.1\r\nTransfer-Encoding: identity\r\n\r\n", DefaultLimits, STATUS_BAD_REQUEST},
+		{"POST / HTTP/1.1\r\nTransfer-Encoding: gzip, chunked\r\n\r\n", DefaultLimits, STATUS_NOT_IMPLEMENTED},
+		{"POST / HTTP/1.1\r\nContent-Length:\r\n\r\n", DefaultLimits, STATUS_BAD_REQUEST},
+		{"GET / HTTP/1.1\r\nHost localhost\r\n\r\n", DefaultLimits, STATUS_BAD_REQUEST},
+		{"GET /" + strings.Repeat("a", 2*MAX_URI_LENGTH), DefaultLimits, STATUS_URI_TOO_LONG},
+		{"GET / HTTP/1.1\r\nX-Large: " + strings.Repeat("a", 64) + "\r\n\r\n", small, STATUS_REQUEST_HEADER_FIELDS_TOO_LARGE},
+		{"GET / HTTP/1.1\r\nA: 1\r\nB: 2\r\nC: 3\r\n\r\n", small, STATUS_REQUEST_HEADER_FIELDS_TOO_LARGE},
+		{"POST / HTTP/1.1\r\nContent-Length: 17\r\n\r\n", small, STATUS_CONTENT_TOO_LARGE},
+		{"POST / HTTP/1.1\r\nContent-Length: 99999999999999999999\r\n\r\n", DefaultLimits, STATUS_BAD_REQUEST},
+		{CHUNKED_REQUEST_HEAD + CRLF + "10\r\n" + strings.Repeat("a", 16) + "\r\n1\r\n", small, STATUS_CONTENT_TOO_LARGE},
+		{CHUNKED_REQUEST_HEAD + CRLF + "0\r\nX-Large: " + strings.Repeat("a", 64) + "\r\n\r\n", small, STATUS_REQUEST_HEADER_FIELDS_TOO_LARGE},
+		{CHUNKED_REQUEST_HEAD + CRLF + "1;" + strings.Repeat("a", maxChunkLineBytes) + "\r\n", DefaultLimits, STATUS_BAD_REQUEST},
+	}
+
+	for _, tt := range tests {
+		_, err := ReadRequest(writeChunks(t, []string{tt.request}, 0), tt.limits)
+
+		requestErr, ok := err.(*RequestError)
+		if !ok {
+			t.Errorf("ReadRequest(%.60q) returned '%v', want a *RequestError", tt.request, err)
+		} else if requestErr.Status != tt.status {
+			t.Errorf("ReadRequest(%.60q) answers %d, want: %d", tt.request, requestErr.Status, tt.status)
+		}
+	}
+}
+
+func TestReadRequestWithinLimits(t *testing.T) {
+	limits := Limits{MaxHeaderBytes: len("Transfer-Encoding: chunked\r\n"), MaxHeaderCount: 1, MaxBodyBytes: 4}
+	reader := writeChunks(t, []string{
+		"POST / HTTP/1.1\r\nContent-Length: 4\r\n\r\nabcd" +
+			"POST / HTTP/1.1\r\nTransfer-Encoding: chunked\r\n\r\n2\r\nab\r\n2\r\ncd\r\n0\r\n\r\n",
+	}, 0)
+
+	for i := 0; i < 2; i++ {
+		if _, err := ReadRequest(reader, limits); err != nil {
+			t.Errorf("ReadRequest() #%d returned error '%s'", i, err)
+		}
+	}
+}
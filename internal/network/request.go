@@ -0,0 +1,87 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"http-server/internal/models"
+)
+
+var ErrRequestTimeout = errors.New("request timeout")
+
+// ReadRequest reads the next request from reader, with its body in memory.
+// It returns as soon as the whole request has arrived, as determined by the
+// message framing, without consuming anything that follows it.
+// It returns io.EOF if the peer closed the connection before sending anything
+// and a *RequestError if the request is malformed or exceeds limits.
+func ReadRequest(reader *bufio.Reader, limits Limits) (models.HttpRequest, error) {
+	request, err := ReadRequestHead(reader, limits)
+	if err != nil {
+		return models.HttpRequest{}, err
+	}
+
+	if err := ReadRequestBody(reader, &request, limits); err != nil {
+		return models.HttpRequest{}, err
+	}
+
+	body, err := request.ReadBody()
+	if err != nil {
+		return models.HttpRequest{}, err
+	}
+
+	request.Body = models.BytesBody(body)
+	return request, nil
+}
+
+// ReadRequestHead reads the request line and header fields of the next
+// request from reader, leaving its body unread.
+// It returns the same errors as ReadRequest.
+func ReadRequestHead(reader *bufio.Reader, limits Limits) (models.HttpRequest, error) {
+	limits = limits.withDefaults()
+
+	lines, err := readHead(reader, limits)
+	if err != nil {
+		return models.HttpRequest{}, err
+	}
+
+	requestLine, err := parseRequestLine(lines[0])
+	if err != nil {
+		return models.HttpRequest{}, err
+	}
+
+	headers, err := parseHeaders(lines[1:])
+	if err != nil {
+		return models.HttpRequest{}, err
+	}
+
+	// A target in absolute-form overrides the Host header.
+	if requestLine.authority != "" {
+		headers.Set("Host", requestLine.authority)
+	}
+
+	return models.HttpRequest{
+		Method:  requestLine.method,
+		Path:    requestLine.path,
+		Version: requestLine.version,
+		Headers: headers,
+	}, nil
+}
+
+// ReadRequestBody sets the Body of a request whose head was read with
+// ReadRequestHead to a *Body that streams it from reader. The trailer fields
+// of a chunked body are added to request.Trailers once it has been read.
+// Only errors in the fields that frame the body are returned, errors in the
+// body itself are returned by its Read method.
+// The body must be read to its end, or discarded, before the next request
+// can be read from reader.
+func ReadRequestBody(reader *bufio.Reader, request *models.HttpRequest, limits Limits) error {
+	limits = limits.withDefaults()
+
+	body, trailers, err := readBody(reader, request.Headers, limits)
+	if err != nil {
+		return err
+	}
+
+	request.Body = body
+	request.Trailers = trailers
+	return nil
+}
@@ -0,0 +1,184 @@
+package network
+
+import (
+	"errors"
+	"strings"
+)
+
+// MAX_URI_LENGTH is the longest request-target the server accepts before it
+// answers with STATUS_URI_TOO_LONG.
+const MAX_URI_LENGTH = 8192
+
+var (
+	ErrMalformedRequestLine = errors.New("malformed request line")
+	ErrInvalidMethod        = errors.New("invalid method")
+	ErrInvalidRequestTarget = errors.New("invalid request target")
+	ErrURITooLong           = errors.New("request target is too long")
+	ErrMalformedVersion     = errors.New("malformed HTTP version")
+	ErrUnsupportedVersion   = errors.New("unsupported HTTP version")
+)
+
+// requestLine holds the parts of a request line.
+// For absolute-form targets, path holds the path and query of the target and
+// authority the host it was addressed to.
+type requestLine struct {
+	method    string
+	path      string
+	version   string
+	authority string
+}
+
+// parseRequestLine parses a request line such as "GET /echo/abc HTTP/1.1".
+// The method must be a token, the target must be in a form allowed for the
+// method and the version must be HTTP/1.0 or HTTP/1.1. Malformed lines are
+// reported as a *RequestError.
+func parseRequestLine(line string) (requestLine, error) {
+	method, rest, found := strings.Cut(line, " ")
+	if !found {
+		return requestLine{}, badRequest(ErrMalformedRequestLine)
+	}
+
+	target, version, found := strings.Cut(rest, " ")
+	if !found || strings.Contains(version, " ") {
+		return requestLine{}, badRequest(ErrMalformedRequestLine)
+	}
+
+	if !isToken(method) {
+		return requestLine{}, badRequest(ErrInvalidMethod)
+	}
+
+	if len(target) > MAX_URI_LENGTH {
+		return requestLine{}, &RequestError{Status: STATUS_URI_TOO_LONG, Err: ErrURITooLong}
+	}
+
+	if err := checkVersion(version); err != nil {
+		return requestLine{}, err
+	}
+
+	path, authority, err := parseRequestTarget(method, target)
+	if err != nil {
+		return requestLine{}, err
+	}
+
+	return requestLine{method: method, path: path, version: version, authority: authority}, nil
+}
+
+// IsMethod reports whether method is a syntactically valid request method,
+// which is any token such as "GET" or an extension method like "PURGE".
+func IsMethod(method string) bool {
+	return isToken(method)
+}
+
+// checkVersion accepts HTTP/1.0 and HTTP/1.1. Other well-formed versions are
+// answered with STATUS_HTTP_VERSION_NOT_SUPPORTED.
+func checkVersion(version string) error {
+	if len(version) != len("HTTP/x.y") || !strings.HasPrefix(version, "HTTP/") ||
+		!isDigit(version[5]) || version[6] != '.' || !isDigit(version[7]) {
+		return badRequest(ErrMalformedVersion)
+	}
+
+	if version != "HTTP/1.0" && version != "HTTP/1.1" {
+		return &RequestError{Status: STATUS_HTTP_VERSION_NOT_SUPPORTED, Err: ErrUnsupportedVersion}
+	}
+
+	return nil
+}
+
+// parseRequestTarget validates target against the four request-target forms
+// and returns the path the request should be routed by.
+//
+//	origin-form     /users/1?verbose=true
+//	absolute-form   http://localhost:4221/users/1
+//	authority-form  localhost:4221       (CONNECT only)
+//	asterisk-form   *                    (OPTIONS only)
+func parseRequestTarget(method, target string) (path, authority string, err error) {
+	switch {
+	case strings.HasPrefix(target, "/"):
+		if !isURIReference(target) {
+			return "", "", badRequest(ErrInvalidRequestTarget)
+		}
+		return target, "", nil
+
+	case target == "*":
+		if method != "OPTIONS" {
+			return "", "", badRequest(ErrInvalidRequestTarget)
+		}
+		return target, "", nil
+
+	case method == "CONNECT":
+		host, port, found := strings.Cut(target, ":")
+		if !found || host == "" || port == "" || !isURIReference(target) || strings.ContainsAny(target, "/?@") {
+			return "", "", badRequest(ErrInvalidRequestTarget)
+		}
+		return target, target, nil
+	}
+
+	scheme, rest, found := strings.Cut(target, "://")
+	if !found || !isScheme(scheme) || !isURIReference(rest) {
+		return "", "", badRequest(ErrInvalidRequestTarget)
+	}
+
+	authority, path = rest, "/"
+	if i := strings.IndexAny(rest, "/?"); i != -1 {
+		authority, path = rest[:i], rest[i:]
+		if path[0] == '?' {
+			path = "/" + path
+		}
+	}
+
+	if authority == "" {
+		return "", "", badRequest(ErrInvalidRequestTarget)
+	}
+
+	return path, authority, nil
+}
+
+// isScheme reports whether value is a URI scheme: a letter followed by
+// letters, digits, "+", "-" or ".".
+func isScheme(value string) bool {
+	if value == "" || !isAlpha(value[0]) {
+		return false
+	}
+
+	for i := 1; i < len(value); i++ {
+		if c := value[i]; !isAlpha(c) && !isDigit(c) && c != '+' && c != '-' && c != '.' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isURIReference reports whether value only contains characters allowed in
+// the path and query of a URI, with every "%" starting a valid escape.
+// Fragments are not allowed in a request target.
+func isURIReference(value string) bool {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		switch {
+		case c == '%':
+			if i+2 >= len(value) || !isHex(value[i+1]) || !isHex(value[i+2]) {
+				return false
+			}
+			i += 2
+		case isAlpha(c) || isDigit(c) || strings.IndexByte("-._~!$&'()*+,;=:@/?[]", c) != -1:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func isAlpha(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -0,0 +1,272 @@
+package network
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	testingutil "http-server/internal/util/testing"
+	"strings"
+	"testing"
+)
+
+type requestLineTest struct {
+	testingutil.BasicTest
+	line   string
+	status int
+}
+
+func (test requestLineTest) String() string {
+	return test.Description
+}
+
+func TestParseRequestLine(t *testing.T) {
+	const TEST_FUNCTION = "parseRequestLine"
+
+	tests := []requestLineTest{
+		{
+			testingutil.BasicTest{
+				Description: "Origin-form",
+				Want:        requestLine{method: "GET", path: "/echo/abc?x=1%202", version: "HTTP/1.1"},
+			},
+			"GET /echo/abc?x=1%202 HTTP/1.1",
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "HTTP/1.0",
+				Want:        requestLine{method: "POST", path: "/", version: "HTTP/1.0"},
+			},
+			"POST / HTTP/1.0",
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Extension method",
+				Want:        requestLine{method: "PURGE", path: "/cache", version: "HTTP/1.1"},
+			},
+			"PURGE /cache HTTP/1.1",
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Absolute-form",
+				Want:        requestLine{method: "GET", path: "/users/1", version: "HTTP/1.1", authority: "localhost:4221"},
+			},
+			"GET http://localhost:4221/users/1 HTTP/1.1",
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Absolute-form without a path",
+				Want:        requestLine{method: "GET", path: "/?id=1", version: "HTTP/1.1", authority: "localhost"},
+			},
+			"GET http://localhost?id=1 HTTP/1.1",
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Asterisk-form for OPTIONS",
+				Want:        requestLine{method: "OPTIONS", path: "*", version: "HTTP/1.1"},
+			},
+			"OPTIONS * HTTP/1.1",
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Authority-form for CONNECT",
+				Want:        requestLine{method: "CONNECT", path: "localhost:443", version: "HTTP/1.1", authority: "localhost:443"},
+			},
+			"CONNECT localhost:443 HTTP/1.1",
+			0,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Missing version",
+				Want:        requestLine{},
+				Error:       ErrMalformedRequestLine.Error(),
+			},
+			"GET /",
+			STATUS_BAD_REQUEST,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Single word",
+				Want:        requestLine{},
+				Error:       ErrMalformedRequestLine.Error(),
+			},
+			"GET",
+			STATUS_BAD_REQUEST,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Double space",
+				Want:        requestLine{},
+				Error:       ErrMalformedRequestLine.Error(),
+			},
+			"GET  / HTTP/1.1",
+			STATUS_BAD_REQUEST,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Trailing space",
+				Want:        requestLine{},
+				Error:       ErrMalformedRequestLine.Error(),
+			},
+			"GET / HTTP/1.1 ",
+			STATUS_BAD_REQUEST,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Method with a separator",
+				Want:        requestLine{},
+				Error:       ErrInvalidMethod.Error(),
+			},
+			"GE(T / HTTP/1.1",
+			STATUS_BAD_REQUEST,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Target with a fragment",
+				Want:        requestLine{},
+				Error:       ErrInvalidRequestTarget.Error(),
+			},
+			"GET /hello#top HTTP/1.1",
+			STATUS_BAD_REQUEST,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Target with an invalid percent escape",
+				Want:        requestLine{},
+				Error:       ErrInvalidRequestTarget.Error(),
+			},
+			"GET /hello%2 HTTP/1.1",
+			STATUS_BAD_REQUEST,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Relative target",
+				Want:        requestLine{},
+				Error:       ErrInvalidRequestTarget.Error(),
+			},
+			"GET hello HTTP/1.1",
+			STATUS_BAD_REQUEST,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Asterisk-form for GET",
+				Want:        requestLine{},
+				Error:       ErrInvalidRequestTarget.Error(),
+			},
+			"GET * HTTP/1.1",
+			STATUS_BAD_REQUEST,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Overlong target",
+				Want:        requestLine{},
+				Error:       ErrURITooLong.Error(),
+			},
+			"GET /" + strings.Repeat("a", MAX_URI_LENGTH) + " HTTP/1.1",
+			STATUS_URI_TOO_LONG,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Lowercase protocol name",
+				Want:        requestLine{},
+				Error:       ErrMalformedVersion.Error(),
+			},
+			"GET / http/1.1",
+			STATUS_BAD_REQUEST,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Unsupported version",
+				Want:        requestLine{},
+				Error:       ErrUnsupportedVersion.Error(),
+			},
+			"GET / HTTP/2.0",
+			STATUS_HTTP_VERSION_NOT_SUPPORTED,
+		},
+	}
+
+	executeTest := func(t *testing.T, tt requestLineTest) requestLine {
+		got, err := parseRequestLine(tt.line)
+		testingutil.ValidateError(t, TEST_FUNCTION, err, tt.Error)
+
+		var requestErr *RequestError
+		switch {
+		case err == nil && tt.Error != "":
+			t.Errorf("%s expected the error '%s' but got none", TEST_FUNCTION, tt.Error)
+		case err != nil && !errors.As(err, &requestErr):
+			t.Errorf("%s returned '%v', want a *RequestError", TEST_FUNCTION, err)
+		case err != nil && requestErr.Status != tt.status:
+			t.Errorf("%s answers %d, want: %d", TEST_FUNCTION, requestErr.Status, tt.status)
+		}
+
+		return got
+	}
+
+	validateTest := func(t *testing.T, tt requestLineTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[requestLine](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%.40q) = %+v, want: %+v", TEST_FUNCTION, tt.line, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func FuzzParseRequestLine(f *testing.F) {
+	for _, seed := range []string{
+		"GET /echo/abc HTTP/1.1",
+		"GET http://localhost:4221/users?id=1 HTTP/1.1",
+		"OPTIONS * HTTP/1.0",
+		"CONNECT localhost:443 HTTP/1.1",
+		"GET /",
+		"GET / HTTP/9.9",
+		"",
+		" ",
+		"%",
+		"GET /%zz HTTP/1.1",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		got, err := parseRequestLine(line)
+		if err != nil {
+			var requestErr *RequestError
+			if !errors.As(err, &requestErr) {
+				t.Fatalf("parseRequestLine(%q) returned '%v', want a *RequestError", line, err)
+			}
+			return
+		}
+
+		if !isToken(got.method) {
+			t.Errorf("parseRequestLine(%q) accepted method %q", line, got.method)
+		}
+		if got.version != "HTTP/1.0" && got.version != "HTTP/1.1" {
+			t.Errorf("parseRequestLine(%q) accepted version %q", line, got.version)
+		}
+		if got.path == "" || (got.path[0] != '/' && got.path != "*" && got.method != "CONNECT") {
+			t.Errorf("parseRequestLine(%q) accepted path %q", line, got.path)
+		}
+	})
+}
+
+func FuzzReadRequest(f *testing.F) {
+	for _, seed := range []string{
+		GET_REQUEST_HEAD + CRLF,
+		POST_REQUEST_HEAD + CRLF + POST_REQUEST_BODY,
+		CHUNKED_REQUEST_HEAD + CRLF + CHUNKED_REQUEST_BODY + "Checksum: abc\r\n" + CRLF,
+		"GET / HTTP/1.1\r\nX-Long: first\r\n  second\r\n\r\n",
+		"GET / HTTP/1.1\r\n folded\r\n\r\n",
+		"\r\n\r\n",
+		"GET",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadRequest(bufio.NewReader(strings.NewReader(string(data))), DefaultLimits)
+	})
+}
@@ -0,0 +1,382 @@
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"http-server/internal/models"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResponseWriter is used by a handler to build the response to a request.
+type ResponseWriter interface {
+	// Header returns the header map that is sent when the response is committed.
+	// Changing it after the first Flush, or after more than the buffered
+	// amount of body has been written, has no effect.
+	Header() models.Header
+
+	// WriteHeader sets the status code of the response.
+	// Only the first call has an effect.
+	WriteHeader(status int)
+
+	// Write appends data to the body, calling WriteHeader(STATUS_OK) first if
+	// no status was set.
+	Write(data []byte) (int, error)
+
+	// Flush sends the response written so far to the client.
+	// A flushed response without a Content-Length header is streamed with
+	// chunked transfer encoding.
+	Flush() error
+
+	// Status returns the status code of the response, which is STATUS_OK
+	// until WriteHeader is called.
+	Status() int
+
+	// Written returns the number of body bytes accepted by Write so far.
+	Written() int64
+}
+
+// RESPONSE_BUFFER_SIZE is how much of a body is buffered before the response
+// is streamed instead of being sent with a Content-Length header.
+const RESPONSE_BUFFER_SIZE = 4096
+
+const dateFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+var (
+	ErrBodyNotAllowed       = errors.New("response status does not allow a body")
+	ErrDeadlineNotSupported = errors.New("connection does not support deadlines")
+)
+
+// Response is the ResponseWriter for a single request on a connection.
+// Bodies that fit in the buffer are sent with a Content-Length header once
+// the handler returns and Finish is called, larger or flushed bodies are
+// streamed as they are written.
+// The body of a response to a HEAD request is counted for its Content-Length
+// and then dropped.
+type Response struct {
+	conn        io.Writer
+	header      models.Header
+	version     string
+	head        bool
+	status      int
+	buffer      []byte
+	discarded   int
+	written     int64
+	committed   bool
+	chunked     bool
+	closeAfter  bool
+	wroteHeader bool
+	err         error
+}
+
+// NewResponse returns a Response that answers request over conn.
+func NewResponse(conn io.Writer, request models.HttpRequest) *Response {
+	return &Response{
+		conn:       conn,
+		header:     models.Header{},
+		version:    request.Version,
+		head:       request.Method == "HEAD",
+		closeAfter: !keepAlive(request),
+	}
+}
+
+func (r *Response) Header() models.Header {
+	return r.header
+}
+
+func (r *Response) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+
+	r.wroteHeader = true
+	r.status = status
+}
+
+func (r *Response) Status() int {
+	if !r.wroteHeader {
+		return STATUS_OK
+	}
+
+	return r.status
+}
+
+func (r *Response) Written() int64 {
+	return r.written
+}
+
+func (r *Response) Write(data []byte) (int, error) {
+	n, err := r.appendBody(data)
+	r.written += int64(n)
+	return n, err
+}
+
+// appendBody buffers or sends data, depending on whether the response has
+// been committed.
+func (r *Response) appendBody(data []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(STATUS_OK)
+	}
+
+	if !bodyAllowed(r.status) {
+		return 0, ErrBodyNotAllowed
+	}
+
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	if r.head {
+		r.discarded += len(data)
+		return len(data), nil
+	}
+
+	if !r.committed {
+		if len(r.buffer)+len(data) <= RESPONSE_BUFFER_SIZE {
+			r.buffer = append(r.buffer, data...)
+			return len(data), nil
+		}
+
+		if err := r.Flush(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := r.writeBody(data); err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
+
+func (r *Response) Flush() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	// The head of a HEAD response waits for Finish, so it can tell the
+	// length of the body that was dropped.
+	if r.committed || r.head {
+		return nil
+	}
+
+	return r.commit()
+}
+
+// commit writes the head and the buffered part of the body to the client.
+func (r *Response) commit() error {
+	if !r.wroteHeader {
+		r.WriteHeader(STATUS_OK)
+	}
+
+	if bodyAllowed(r.status) && r.header.Get("Content-Length") == "" {
+		if r.version == "HTTP/1.1" {
+			r.chunked = true
+			r.header.Set("Transfer-Encoding", "chunked")
+		} else {
+			// Older clients cannot decode chunks, so the end of the
+			// connection marks the end of the body instead.
+			r.closeAfter = true
+		}
+	}
+
+	if err := r.writeHead(); err != nil {
+		return err
+	}
+
+	buffer := r.buffer
+	r.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+
+	return r.writeBody(buffer)
+}
+
+// Finish completes the response once the handler has returned.
+// A buffered response is sent with a Content-Length header, and a chunked
+// one is terminated with the last chunk.
+func (r *Response) Finish() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	if !r.committed {
+		if !r.wroteHeader {
+			r.WriteHeader(STATUS_OK)
+		}
+
+		if bodyAllowed(r.status) && r.header.Get("Content-Length") == "" {
+			r.header.Set("Content-Length", strconv.Itoa(len(r.buffer)+r.discarded))
+		}
+
+		return r.commit()
+	}
+
+	if r.chunked {
+		return r.write([]byte("0" + CRLF + CRLF))
+	}
+
+	return nil
+}
+
+// Committed reports whether the head of the response has been sent.
+func (r *Response) Committed() bool {
+	return r.committed
+}
+
+// SetWriteDeadline sets the time by which the rest of the response must have
+// been sent. A write that does not finish by then fails, and the connection
+// is not used for another request.
+func (r *Response) SetWriteDeadline(deadline time.Time) error {
+	conn, ok := r.conn.(interface{ SetWriteDeadline(time.Time) error })
+	if !ok {
+		return ErrDeadlineNotSupported
+	}
+
+	return conn.SetWriteDeadline(deadline)
+}
+
+// Reset discards the status, header and buffered body of a response that has
+// not been committed, so a different response can be written instead.
+// It reports whether the response could be reset.
+func (r *Response) Reset() bool {
+	if r.committed || r.err != nil {
+		return false
+	}
+
+	r.header = models.Header{}
+	r.status = 0
+	r.wroteHeader = false
+	r.buffer = nil
+	r.discarded = 0
+	r.written = 0
+	return true
+}
+
+// KeepAlive reports whether the connection can serve another request after
+// this response has been finished.
+func (r *Response) KeepAlive() bool {
+	return !r.closeAfter && r.err == nil
+}
+
+func (r *Response) writeHead() error {
+	r.committed = true
+
+	if hasToken(r.header, "Connection", "close") {
+		r.closeAfter = true
+	}
+
+	if r.closeAfter {
+		r.header.Set("Connection", "close")
+	} else if r.version == "HTTP/1.0" {
+		r.header.Set("Connection", "keep-alive")
+	}
+
+	if r.header.Get("Date") == "" {
+		r.header.Set("Date", time.Now().UTC().Format(dateFormat))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(statusLine(r.status))
+
+	names := make([]string, 0, len(r.header))
+	for name := range r.header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range r.header[name] {
+			sb.WriteString(name + ": " + value + CRLF)
+		}
+	}
+	sb.WriteString(CRLF)
+
+	return r.write([]byte(sb.String()))
+}
+
+func (r *Response) writeBody(data []byte) error {
+	if !r.chunked {
+		return r.write(data)
+	}
+
+	chunk := make([]byte, 0, len(data)+20)
+	chunk = append(chunk, strconv.FormatInt(int64(len(data)), 16)+CRLF...)
+	chunk = append(chunk, data...)
+	chunk = append(chunk, CRLF...)
+
+	return r.write(chunk)
+}
+
+func (r *Response) write(data []byte) error {
+	if _, err := r.conn.Write(data); err != nil {
+		r.err = err
+		return err
+	}
+
+	return nil
+}
+
+// SendText writes text as a plain text response with the given status.
+func SendText(w ResponseWriter, status int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(text))
+}
+
+// SendHTML writes html as an HTML response with the given status.
+func SendHTML(w ResponseWriter, status int, html string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write([]byte(html))
+}
+
+// SendJSON writes value encoded as JSON with the given status.
+// It answers with STATUS_INTERNAL_SERVER_ERROR if value cannot be encoded.
+func SendJSON(w ResponseWriter, status int, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		SendText(w, STATUS_INTERNAL_SERVER_ERROR, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+func statusLine(status int) string {
+	if line, found := statusLines[status]; found {
+		return line
+	}
+
+	return fmt.Sprintf("HTTP/1.1 %d \r\n", status)
+}
+
+// bodyAllowed reports whether a response with status may carry a body.
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != STATUS_NO_CONTENT && status != STATUS_NOT_MODIFIED
+}
+
+// keepAlive reports whether the connection may be reused after answering request.
+// HTTP/1.1 connections persist unless the client sends "Connection: close",
+// while HTTP/1.0 connections persist only if the client asks for "keep-alive".
+func keepAlive(request models.HttpRequest) bool {
+	if hasToken(request.Headers, "Connection", "close") {
+		return false
+	}
+
+	switch request.Version {
+	case "HTTP/1.1":
+		return true
+	case "HTTP/1.0":
+		return hasToken(request.Headers, "Connection", "keep-alive")
+	default:
+		return false
+	}
+}
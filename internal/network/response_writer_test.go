@@ -0,0 +1,185 @@
+package network
+
+import (
+	"bytes"
+	"fmt"
+	"http-server/internal/models"
+	testingutil "http-server/internal/util/testing"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type responseTest struct {
+	testingutil.BasicTest
+	request models.HttpRequest
+	handler func(w ResponseWriter)
+}
+
+func (test responseTest) String() string {
+	return test.Description
+}
+
+var datePattern = regexp.MustCompile(`Date: [^\r]*\r\n`)
+
+type responseResult struct {
+	response  string
+	keepAlive bool
+}
+
+func TestResponse(t *testing.T) {
+	const TEST_FUNCTION = "Response"
+
+	http11 := models.HttpRequest{Version: "HTTP/1.1"}
+	large := strings.Repeat("a", RESPONSE_BUFFER_SIZE+1)
+
+	tests := []responseTest{
+		{
+			testingutil.BasicTest{
+				Description: "Buffered body gets a Content-Length",
+				Want: responseResult{
+					"HTTP/1.1 200 OK\r\nContent-Length: 11\r\nContent-Type: text/plain; charset=utf-8\r\n\r\nHello World",
+					true,
+				},
+			},
+			http11,
+			func(w ResponseWriter) { SendText(w, STATUS_OK, "Hello World") },
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Handler that writes nothing sends an empty 200",
+				Want:        responseResult{"HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n", true},
+			},
+			http11,
+			func(w ResponseWriter) {},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "No Content responses have no body",
+				Want:        responseResult{"HTTP/1.1 204 No Content\r\n\r\n", true},
+			},
+			http11,
+			func(w ResponseWriter) {
+				w.WriteHeader(STATUS_NO_CONTENT)
+				w.Write([]byte("ignored"))
+			},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Flushed body is chunked",
+				Want: responseResult{
+					"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nHello\r\n6\r\n World\r\n0\r\n\r\n",
+					true,
+				},
+			},
+			http11,
+			func(w ResponseWriter) {
+				w.Write([]byte("Hello"))
+				w.Flush()
+				w.Write([]byte(" World"))
+			},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Body larger than the buffer is chunked",
+				Want: responseResult{
+					fmt.Sprintf("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n%x\r\n%s\r\n0\r\n\r\n", len(large), large),
+					true,
+				},
+			},
+			http11,
+			func(w ResponseWriter) { w.Write([]byte(large)) },
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Declared Content-Length is streamed as is",
+				Want:        responseResult{"HTTP/1.1 200 OK\r\nContent-Length: 5\r\n\r\nHello", true},
+			},
+			http11,
+			func(w ResponseWriter) {
+				w.Header().Set("Content-Length", "5")
+				w.Write([]byte("Hello"))
+				w.Flush()
+			},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "HEAD response keeps the Content-Length but drops the body",
+				Want: responseResult{
+					"HTTP/1.1 200 OK\r\nContent-Length: 11\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n",
+					true,
+				},
+			},
+			models.HttpRequest{Method: "HEAD", Version: "HTTP/1.1"},
+			func(w ResponseWriter) { SendText(w, STATUS_OK, "Hello World") },
+		},
+		{
+			testingutil.BasicTest{
+				Description: "HEAD response to a streamed body is not chunked",
+				Want:        responseResult{"HTTP/1.1 200 OK\r\nContent-Length: 4102\r\n\r\n", true},
+			},
+			models.HttpRequest{Method: "HEAD", Version: "HTTP/1.1"},
+			func(w ResponseWriter) {
+				w.Write([]byte("Hello"))
+				w.Flush()
+				w.Write([]byte(large))
+			},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Client asks to close the connection",
+				Want:        responseResult{"HTTP/1.1 200 OK\r\nConnection: close\r\nContent-Length: 0\r\n\r\n", false},
+			},
+			models.HttpRequest{Version: "HTTP/1.1", Headers: models.Header{"Connection": {"close"}}},
+			func(w ResponseWriter) {},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "HTTP/1.0 closes by default",
+				Want:        responseResult{"HTTP/1.1 200 OK\r\nConnection: close\r\nContent-Length: 0\r\n\r\n", false},
+			},
+			models.HttpRequest{Version: "HTTP/1.0"},
+			func(w ResponseWriter) {},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "HTTP/1.0 keep-alive is acknowledged",
+				Want:        responseResult{"HTTP/1.1 200 OK\r\nConnection: keep-alive\r\nContent-Length: 0\r\n\r\n", true},
+			},
+			models.HttpRequest{Version: "HTTP/1.0", Headers: models.Header{"Connection": {"Keep-Alive"}}},
+			func(w ResponseWriter) {},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "HTTP/1.0 streaming is delimited by closing the connection",
+				Want:        responseResult{"HTTP/1.1 200 OK\r\nConnection: close\r\n\r\nHello", false},
+			},
+			models.HttpRequest{Version: "HTTP/1.0", Headers: models.Header{"Connection": {"keep-alive"}}},
+			func(w ResponseWriter) {
+				w.Write([]byte("Hello"))
+				w.Flush()
+			},
+		},
+	}
+
+	executeTest := func(t *testing.T, tt responseTest) responseResult {
+		var conn bytes.Buffer
+		response := NewResponse(&conn, tt.request)
+
+		tt.handler(response)
+		if err := response.Finish(); err != nil {
+			t.Errorf("%s.Finish() returned error '%s'", TEST_FUNCTION, err)
+		}
+
+		// The Date header changes with every run, strip it to compare the rest.
+		return responseResult{datePattern.ReplaceAllString(conn.String(), ""), response.KeepAlive()}
+	}
+
+	validateTest := func(t *testing.T, tt responseTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[responseResult](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s: %s = %+v, want: %+v", TEST_FUNCTION, tt.Description, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
@@ -3,14 +3,74 @@ package network
 const RESPONSE_OK string = "HTTP/1.1 200 OK\r\n"
 const RESPONSE_CREATED string = "HTTP/1.1 201 Created\r\n"
 const RESPONSE_NO_CONTENT string = "HTTP/1.1 204 No Content\r\n"
+const RESPONSE_PARTIAL_CONTENT string = "HTTP/1.1 206 Partial Content\r\n"
 const RESPONSE_MOVED_PERMANENTLY string = "HTTP/1.1 301 Moved Permanently\r\n"
 const RESPONSE_FOUND string = "HTTP/1.1 302 Found\r\n"
+const RESPONSE_NOT_MODIFIED string = "HTTP/1.1 304 Not Modified\r\n"
+const RESPONSE_PERMANENT_REDIRECT string = "HTTP/1.1 308 Permanent Redirect\r\n"
 const RESPONSE_BAD_REQUEST string = "HTTP/1.1 400 Bad Request\r\n"
 const RESPONSE_UNAUTHORIZED string = "HTTP/1.1 401 Unauthorized\r\n"
 const RESPONSE_FORBIDDEN string = "HTTP/1.1 403 Forbidden\r\n"
 const RESPONSE_NOT_FOUND string = "HTTP/1.1 404 Not Found\r\n"
 const RESPONSE_METHOD_NOT_ALLOWED string = "HTTP/1.1 405 Method Not Allowed\r\n"
+const RESPONSE_REQUEST_TIMEOUT string = "HTTP/1.1 408 Request Timeout\r\n"
+const RESPONSE_CONTENT_TOO_LARGE string = "HTTP/1.1 413 Content Too Large\r\n"
+const RESPONSE_URI_TOO_LONG string = "HTTP/1.1 414 URI Too Long\r\n"
+const RESPONSE_REQUEST_HEADER_FIELDS_TOO_LARGE string = "HTTP/1.1 431 Request Header Fields Too Large\r\n"
 const RESPONSE_INTERNAL_SERVER_ERROR string = "HTTP/1.1 500 Internal Server Error\r\n"
+const RESPONSE_NOT_IMPLEMENTED string = "HTTP/1.1 501 Not Implemented\r\n"
 const RESPONSE_BAD_GATEWAY string = "HTTP/1.1 502 Bad Gateway\r\n"
 const RESPONSE_SERVICE_UNAVAILABLE string = "HTTP/1.1 503 Service Unavailable\r\n"
+const RESPONSE_HTTP_VERSION_NOT_SUPPORTED string = "HTTP/1.1 505 HTTP Version Not Supported\r\n"
 const CRLF = "\r\n"
+
+const (
+	STATUS_OK                              = 200
+	STATUS_CREATED                         = 201
+	STATUS_NO_CONTENT                      = 204
+	STATUS_PARTIAL_CONTENT                 = 206
+	STATUS_MOVED_PERMANENTLY               = 301
+	STATUS_FOUND                           = 302
+	STATUS_NOT_MODIFIED                    = 304
+	STATUS_PERMANENT_REDIRECT              = 308
+	STATUS_BAD_REQUEST                     = 400
+	STATUS_UNAUTHORIZED                    = 401
+	STATUS_FORBIDDEN                       = 403
+	STATUS_NOT_FOUND                       = 404
+	STATUS_METHOD_NOT_ALLOWED              = 405
+	STATUS_REQUEST_TIMEOUT                 = 408
+	STATUS_CONTENT_TOO_LARGE               = 413
+	STATUS_URI_TOO_LONG                    = 414
+	STATUS_REQUEST_HEADER_FIELDS_TOO_LARGE = 431
+	STATUS_INTERNAL_SERVER_ERROR           = 500
+	STATUS_NOT_IMPLEMENTED                 = 501
+	STATUS_BAD_GATEWAY                     = 502
+	STATUS_SERVICE_UNAVAILABLE             = 503
+	STATUS_HTTP_VERSION_NOT_SUPPORTED      = 505
+)
+
+// statusLines maps a status code to the status line a response starts with.
+var statusLines = map[int]string{
+	STATUS_OK:                              RESPONSE_OK,
+	STATUS_CREATED:                         RESPONSE_CREATED,
+	STATUS_NO_CONTENT:                      RESPONSE_NO_CONTENT,
+	STATUS_PARTIAL_CONTENT:                 RESPONSE_PARTIAL_CONTENT,
+	STATUS_MOVED_PERMANENTLY:               RESPONSE_MOVED_PERMANENTLY,
+	STATUS_FOUND:                           RESPONSE_FOUND,
+	STATUS_NOT_MODIFIED:                    RESPONSE_NOT_MODIFIED,
+	STATUS_PERMANENT_REDIRECT:              RESPONSE_PERMANENT_REDIRECT,
+	STATUS_BAD_REQUEST:                     RESPONSE_BAD_REQUEST,
+	STATUS_UNAUTHORIZED:                    RESPONSE_UNAUTHORIZED,
+	STATUS_FORBIDDEN:                       RESPONSE_FORBIDDEN,
+	STATUS_NOT_FOUND:                       RESPONSE_NOT_FOUND,
+	STATUS_METHOD_NOT_ALLOWED:              RESPONSE_METHOD_NOT_ALLOWED,
+	STATUS_REQUEST_TIMEOUT:                 RESPONSE_REQUEST_TIMEOUT,
+	STATUS_CONTENT_TOO_LARGE:               RESPONSE_CONTENT_TOO_LARGE,
+	STATUS_URI_TOO_LONG:                    RESPONSE_URI_TOO_LONG,
+	STATUS_REQUEST_HEADER_FIELDS_TOO_LARGE: RESPONSE_REQUEST_HEADER_FIELDS_TOO_LARGE,
+	STATUS_INTERNAL_SERVER_ERROR:           RESPONSE_INTERNAL_SERVER_ERROR,
+	STATUS_NOT_IMPLEMENTED:                 RESPONSE_NOT_IMPLEMENTED,
+	STATUS_BAD_GATEWAY:                     RESPONSE_BAD_GATEWAY,
+	STATUS_SERVICE_UNAVAILABLE:             RESPONSE_SERVICE_UNAVAILABLE,
+	STATUS_HTTP_VERSION_NOT_SUPPORTED:      RESPONSE_HTTP_VERSION_NOT_SUPPORTED,
+}
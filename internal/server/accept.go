@@ -0,0 +1,86 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// Bounds of the delay before Accept is retried after an error.
+const (
+	minAcceptBackoff = 5 * time.Millisecond
+	maxAcceptBackoff = time.Second
+)
+
+// acceptLogInterval is the least time between two logged Accept errors.
+const acceptLogInterval = time.Second
+
+// WithErrorLog makes the server log errors and recovered panics to logger
+// instead of the standard logger.
+func WithErrorLog(logger *log.Logger) Option {
+	return func(s *Server) {
+		s.errorLog = logger
+	}
+}
+
+// accept waits for the next connection. Errors such as running out of file
+// descriptors or a connection aborted before it was accepted are retried
+// with exponential backoff, so only a closed listener ends the loop.
+func (s *Server) accept(limiter *rateLimitedLog) (net.Conn, error) {
+	backoff := time.Duration(0)
+	for {
+		conn, err := s.listener.Accept()
+		if err == nil {
+			return conn, nil
+		}
+
+		if s.shuttingDown.Load() {
+			return nil, ErrServerClosed
+		}
+		if errors.Is(err, net.ErrClosed) {
+			return nil, err
+		}
+
+		if backoff == 0 {
+			backoff = minAcceptBackoff
+		} else {
+			backoff = min(2*backoff, maxAcceptBackoff)
+		}
+
+		limiter.printf("Error accepting connection: %s; retrying in %s", err, backoff)
+		time.Sleep(backoff)
+	}
+}
+
+// rateLimitedLog logs at most one message per interval, and counts the
+// messages it drops in between.
+type rateLimitedLog struct {
+	logger   *log.Logger
+	interval time.Duration
+
+	mu         sync.Mutex
+	last       time.Time
+	suppressed int
+}
+
+func (l *rateLimitedLog) printf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.last) < l.interval {
+		l.suppressed++
+		return
+	}
+
+	if l.suppressed > 0 {
+		format += " (%d similar messages suppressed)"
+		args = append(args, l.suppressed)
+	}
+
+	l.logger.Printf(format, args...)
+	l.last = now
+	l.suppressed = 0
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"http-server/internal/handlers"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	testingutil "http-server/internal/util/testing"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeListener is a net.Listener whose Accept returns the errors it is given,
+// then the connections it is given, and then net.ErrClosed.
+type fakeListener struct {
+	mu     sync.Mutex
+	errs   []error
+	conns  []net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newFakeListener(errs []error, conns []net.Conn) *fakeListener {
+	return &fakeListener{errs: errs, conns: conns, closed: make(chan struct{})}
+}
+
+func (l *fakeListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	if len(l.errs) > 0 {
+		err := l.errs[0]
+		l.errs = l.errs[1:]
+		l.mu.Unlock()
+		return nil, err
+	}
+	if len(l.conns) > 0 {
+		conn := l.conns[0]
+		l.conns = l.conns[1:]
+		l.mu.Unlock()
+		return conn, nil
+	}
+	l.mu.Unlock()
+
+	<-l.closed
+	return nil, net.ErrClosed
+}
+
+func (l *fakeListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *fakeListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+}
+
+type acceptTest struct {
+	testingutil.BasicTest
+	errs []error
+}
+
+func (test acceptTest) String() string {
+	return test.Description
+}
+
+// acceptResult is what Serve did with a fakeListener.
+type acceptResult struct {
+	response     string
+	loggedLines  int
+	stillServing bool
+}
+
+func TestAcceptErrors(t *testing.T) {
+	const TEST_FUNCTION = "Server.Serve"
+
+	router := handlers.NewRouter()
+	router.Handle(handlers.GET, "/hello", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_OK, "hello")
+	})
+
+	tests := []acceptTest{
+		{
+			testingutil.BasicTest{
+				Description: "No errors",
+				Want:        acceptResult{"HTTP/1.1 200 OK", 0, true},
+			},
+			nil,
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Out of file descriptors",
+				Want:        acceptResult{"HTTP/1.1 200 OK", 1, true},
+			},
+			[]error{syscall.EMFILE},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Repeated errors are logged once per interval",
+				Want:        acceptResult{"HTTP/1.1 200 OK", 1, true},
+			},
+			[]error{syscall.EMFILE, syscall.ECONNABORTED, syscall.EMFILE, syscall.ENFILE, syscall.ECONNABORTED},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Wrapped errors",
+				Want:        acceptResult{"HTTP/1.1 200 OK", 1, true},
+			},
+			[]error{&net.OpError{Op: "accept", Net: "tcp", Err: syscall.ECONNABORTED}},
+		},
+	}
+
+	executeTest := func(t *testing.T, tt acceptTest) acceptResult {
+		client, conn := net.Pipe()
+		defer client.Close()
+
+		var logs bytes.Buffer
+		listener := newFakeListener(tt.errs, []net.Conn{conn})
+		s, err := New(WithListener(listener), WithRouter(router), WithErrorLog(log.New(&logs, "", 0)))
+		if err != nil {
+			t.Fatalf("New returned '%s'", err)
+		}
+
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- s.Serve()
+		}()
+
+		client.SetDeadline(time.Now().Add(2 * time.Second))
+		fmt.Fprintf(client, "GET /hello HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n")
+		response, _ := io.ReadAll(client)
+		statusLine, _, _ := strings.Cut(string(response), "\r\n")
+
+		result := acceptResult{response: statusLine}
+		select {
+		case err := <-serveErr:
+			t.Errorf("%s returned '%v' before Shutdown", TEST_FUNCTION, err)
+		default:
+			result.stillServing = true
+		}
+
+		s.Close()
+		if err := <-serveErr; !errors.Is(err, ErrServerClosed) {
+			t.Errorf("%s returned '%v' after Close, want: '%s'", TEST_FUNCTION, err, ErrServerClosed)
+		}
+
+		result.loggedLines = strings.Count(logs.String(), "\n")
+		return result
+	}
+
+	validateTest := func(t *testing.T, tt acceptTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[acceptResult](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s with Accept errors %v = %+v, want: %+v", TEST_FUNCTION, tt.errs, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestServeReturnsWhenListenerIsClosedElsewhere(t *testing.T) {
+	listener := newFakeListener(nil, nil)
+	s, err := New(WithListener(listener), WithRouter(handlers.NewRouter()))
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.Serve()
+	}()
+
+	listener.Close()
+	if err := <-serveErr; !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Server.Serve returned '%v', want: '%s'", err, net.ErrClosed)
+	}
+}
+
+func TestAcceptBackoff(t *testing.T) {
+	client, conn := net.Pipe()
+	defer client.Close()
+	defer conn.Close()
+
+	errs := []error{syscall.EMFILE, syscall.EMFILE, syscall.EMFILE, syscall.EMFILE, syscall.EMFILE}
+	listener := newFakeListener(errs, []net.Conn{conn})
+	s, err := New(WithListener(listener), WithRouter(handlers.NewRouter()), WithErrorLog(log.New(io.Discard, "", 0)))
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+
+	start := time.Now()
+	if _, err := s.accept(&rateLimitedLog{logger: s.errorLog, interval: acceptLogInterval}); err != nil {
+		t.Fatalf("accept returned '%s'", err)
+	}
+	elapsed := time.Since(start)
+
+	want := minAcceptBackoff * (1 + 2 + 4 + 8 + 16)
+	if elapsed < want {
+		t.Errorf("accept retried %d errors in %s, want at least %s", len(errs), elapsed, want)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// connReader reads from a connection on behalf of the request parser.
+// While a handler runs, it keeps a read pending in the background so that a
+// client that hangs up cancels the context of its request. A byte received
+// by that read, which belongs to the next pipelined request, is handed to the
+// parser by the next Read.
+type connReader struct {
+	conn net.Conn
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	inRead  bool
+	aborted bool
+	hasByte bool
+	byteBuf [1]byte
+	err     error
+}
+
+func newConnReader(conn net.Conn) *connReader {
+	cr := &connReader{conn: conn}
+	cr.cond = sync.NewCond(&cr.mu)
+	return cr
+}
+
+func (cr *connReader) Read(p []byte) (int, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if cr.inRead {
+		panic("connReader: Read while a background read is pending")
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if cr.hasByte {
+		p[0] = cr.byteBuf[0]
+		cr.hasByte = false
+		return 1, nil
+	}
+
+	if cr.err != nil {
+		return 0, cr.err
+	}
+
+	cr.mu.Unlock()
+	n, err := cr.conn.Read(p)
+	cr.mu.Lock()
+
+	return n, err
+}
+
+// startBackgroundRead waits for the client in the background and calls
+// cancel if it hangs up before abortPendingRead is called.
+func (cr *connReader) startBackgroundRead(cancel context.CancelFunc) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if cr.inRead || cr.hasByte || cr.err != nil {
+		return
+	}
+
+	cr.inRead = true
+	cr.conn.SetReadDeadline(time.Time{})
+	go cr.backgroundRead(cancel)
+}
+
+func (cr *connReader) backgroundRead(cancel context.CancelFunc) {
+	n, err := cr.conn.Read(cr.byteBuf[:])
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if n == 1 {
+		cr.hasByte = true
+	}
+
+	if err != nil && !(cr.aborted && errors.Is(err, os.ErrDeadlineExceeded)) {
+		// The client hung up, or the connection broke.
+		cr.err = err
+		cancel()
+	}
+
+	cr.aborted = false
+	cr.inRead = false
+	cr.cond.Broadcast()
+}
+
+// abortPendingRead stops the background read, if there is one, and waits
+// for it to return.
+func (cr *connReader) abortPendingRead() {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if !cr.inRead {
+		return
+	}
+
+	cr.aborted = true
+	cr.conn.SetReadDeadline(aLongTimeAgo)
+	for cr.inRead {
+		cr.cond.Wait()
+	}
+	cr.conn.SetReadDeadline(time.Time{})
+}
+
+// aLongTimeAgo is a read deadline that makes a pending read return at once.
+var aLongTimeAgo = time.Unix(1, 0)
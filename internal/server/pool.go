@@ -0,0 +1,179 @@
+package server
+
+import (
+	"fmt"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	"net"
+	"strconv"
+	"time"
+)
+
+// DEFAULT_RETRY_AFTER is how long clients that are turned away are asked to
+// wait before they try again, unless set with WithRetryAfter.
+const DEFAULT_RETRY_AFTER = time.Second
+
+// rejectTimeout bounds how long a turned away connection may take to receive
+// its response.
+const rejectTimeout = time.Second
+
+// Stats describes how busy a Server is.
+type Stats struct {
+	// Open is the number of connections that are served or queued.
+	Open int
+	// Active is the number of connections that are reading or answering a
+	// request, rather than waiting for one.
+	Active int
+	// Workers is the number of connections that are assigned a worker.
+	Workers int
+	// MaxWorkers is the size of the worker pool, or 0 if it is unbounded.
+	MaxWorkers int
+	// Queued is the number of accepted connections waiting for a worker.
+	Queued int
+	// QueueSize is how many connections may wait for a worker.
+	QueueSize int
+	// Rejected is the number of connections turned away with
+	// STATUS_SERVICE_UNAVAILABLE since the server started.
+	Rejected uint64
+}
+
+func (stats Stats) String() string {
+	return fmt.Sprintf("open=%d active=%d workers=%d/%d queued=%d/%d rejected=%d",
+		stats.Open, stats.Active, stats.Workers, stats.MaxWorkers, stats.Queued, stats.QueueSize, stats.Rejected)
+}
+
+// WithMaxConnections limits the number of connections served at the same
+// time to n, each by one worker of a pool. 0, the default, serves every
+// connection in its own goroutine.
+// An idle keep-alive connection is closed to free its worker when another
+// connection has to wait for one.
+func WithMaxConnections(n int) Option {
+	return func(s *Server) {
+		s.maxWorkers = n
+	}
+}
+
+// WithQueueSize lets up to n accepted connections wait for a worker when all
+// of them are busy. Further connections are answered with
+// STATUS_SERVICE_UNAVAILABLE. It has no effect without WithMaxConnections.
+func WithQueueSize(n int) Option {
+	return func(s *Server) {
+		s.queueSize = n
+	}
+}
+
+// WithRetryAfter sets the Retry-After header sent to connections that are
+// turned away.
+func WithRetryAfter(delay time.Duration) Option {
+	return func(s *Server) {
+		s.retryAfter = delay
+	}
+}
+
+// Stats returns how busy the server is.
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := Stats{
+		Open:       len(s.conns),
+		Workers:    int(s.busyWorkers.Load()),
+		MaxWorkers: s.maxWorkers,
+		Queued:     len(s.queue),
+		QueueSize:  s.queueSize,
+		Rejected:   s.rejected.Load(),
+	}
+
+	for _, state := range s.conns {
+		if state == stateActive {
+			stats.Active++
+		}
+	}
+
+	return stats
+}
+
+// startWorkers starts the worker pool, if the server has one.
+func (s *Server) startWorkers() {
+	if s.queue == nil {
+		return
+	}
+
+	for i := 0; i < s.maxWorkers; i++ {
+		go s.work()
+	}
+}
+
+// stopWorkers lets the workers exit once the queue is empty.
+func (s *Server) stopWorkers() {
+	if s.queue != nil {
+		close(s.queue)
+	}
+}
+
+func (s *Server) work() {
+	for conn := range s.queue {
+		s.busyWorkers.Add(1)
+		s.handleConnection(conn)
+		s.busyWorkers.Add(-1)
+	}
+}
+
+// dispatch hands an accepted connection to a worker, queues it or turns it
+// away if the queue is full.
+func (s *Server) dispatch(conn net.Conn) {
+	s.trackConn(conn, stateQueued)
+
+	if s.queue == nil {
+		go s.handleConnection(conn)
+		return
+	}
+
+	select {
+	case s.queue <- conn:
+		if s.busyWorkers.Load() >= int64(s.maxWorkers) {
+			s.closeIdleWorkers(len(s.queue))
+		}
+	default:
+		s.forgetConn(conn)
+		s.rejected.Add(1)
+		go s.reject(conn)
+	}
+}
+
+// closeIdleWorkers closes up to n of the connections that hold a worker while
+// they wait for a request, so their workers can serve queued connections.
+func (s *Server) closeIdleWorkers(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn, state := range s.conns {
+		if n <= 0 {
+			return
+		}
+		if state == stateIdle {
+			conn.Close()
+			delete(s.conns, conn)
+			n--
+		}
+	}
+}
+
+// saturated reports whether connections are waiting for a worker.
+func (s *Server) saturated() bool {
+	return s.queue != nil && len(s.queue) > 0
+}
+
+// reject answers conn with STATUS_SERVICE_UNAVAILABLE and closes it without
+// reading a request.
+func (s *Server) reject(conn net.Conn) {
+	conn.SetDeadline(time.Now().Add(rejectTimeout))
+
+	seconds := int((s.retryAfter + time.Second - 1) / time.Second)
+
+	response := network.NewResponse(conn, models.HttpRequest{})
+	response.Header().Set("Retry-After", strconv.Itoa(seconds))
+	network.SendText(response, network.STATUS_SERVICE_UNAVAILABLE, "Server is busy, try again later")
+	response.Finish()
+	network.CloseAfterError(conn)
+}
@@ -0,0 +1,520 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"http-server/internal/data/database"
+	userrepository "http-server/internal/data/repositories/user"
+	"http-server/internal/handlers"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	"io"
+	"log"
+	"net"
+	"os"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DEFAULT_ADDRESS is the address a Server listens on unless it is given a
+// listener or another address.
+const DEFAULT_ADDRESS = "0.0.0.0:4221"
+
+// Default timeouts of a connection, unless set with the options of the same
+// name.
+const (
+	// DEFAULT_IDLE_TIMEOUT is how long a persistent connection may wait for
+	// the first byte of its next request.
+	DEFAULT_IDLE_TIMEOUT = 60 * time.Second
+	// DEFAULT_READ_HEADER_TIMEOUT is how long the rest of the request line
+	// and header fields may take to arrive after their first byte.
+	DEFAULT_READ_HEADER_TIMEOUT = 10 * time.Second
+	// DEFAULT_READ_BODY_TIMEOUT is how long the body may take to arrive after
+	// the header fields.
+	DEFAULT_READ_BODY_TIMEOUT = 30 * time.Second
+	// DEFAULT_WRITE_TIMEOUT is how long a request may take to be answered
+	// once it has been read.
+	DEFAULT_WRITE_TIMEOUT = 30 * time.Second
+)
+
+// Server accepts connections on a listener and answers the requests on them
+// with a Router. Every Server owns its listener, router and database, so
+// several can run side by side in the same process.
+type Server struct {
+	address  string
+	listener net.Listener
+	db       database.DbRepository
+	ownsDb   bool
+	router   *handlers.Router
+	limits   network.Limits
+	errorLog *log.Logger
+
+	idleTimeout       time.Duration
+	readHeaderTimeout time.Duration
+	readBodyTimeout   time.Duration
+	writeTimeout      time.Duration
+
+	maxWorkers  int
+	queueSize   int
+	retryAfter  time.Duration
+	queue       chan net.Conn
+	busyWorkers atomic.Int64
+	rejected    atomic.Uint64
+
+	mu           sync.Mutex
+	conns        map[net.Conn]connState
+	shuttingDown atomic.Bool
+
+	// baseCtx is the parent of every request context. It is cancelled when
+	// the server stops waiting for active requests.
+	baseCtx    context.Context
+	cancelBase context.CancelFunc
+}
+
+// ErrServerClosed is returned by Serve once Shutdown or Close has been called.
+var ErrServerClosed = errors.New("server closed")
+
+// maxDiscardBytes is how much of a body left unread by its handler is read
+// and dropped to keep the connection open for the next request.
+const maxDiscardBytes = 256 << 10
+
+// shutdownPollInterval is how often Shutdown checks whether the active
+// connections have finished.
+const shutdownPollInterval = 10 * time.Millisecond
+
+// Option configures a Server created by New.
+type Option func(*Server)
+
+// WithAddress makes the server listen on address, such as "127.0.0.1:0".
+// It has no effect if a listener is given with WithListener.
+func WithAddress(address string) Option {
+	return func(s *Server) {
+		s.address = address
+	}
+}
+
+// WithListener makes the server accept connections from listener instead of
+// listening on an address itself.
+func WithListener(listener net.Listener) Option {
+	return func(s *Server) {
+		s.listener = listener
+	}
+}
+
+// WithDatabase makes the default routes store their data in db.
+// Without it, a server that needs a database uses a private in-memory one.
+func WithDatabase(db database.DbRepository) Option {
+	return func(s *Server) {
+		s.db = db
+	}
+}
+
+// WithRouter makes the server answer requests with router instead of the
+// default routes.
+func WithRouter(router *handlers.Router) Option {
+	return func(s *Server) {
+		s.router = router
+	}
+}
+
+// WithIdleTimeout sets how long a persistent connection may wait for its
+// next request before it is closed. 0 lets it wait forever.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.idleTimeout = timeout
+	}
+}
+
+// WithReadHeaderTimeout sets how long the request line and header fields may
+// take to arrive once the first byte of a request has been received. Clients
+// that are slower are answered with STATUS_REQUEST_TIMEOUT. 0 disables the
+// timeout.
+func WithReadHeaderTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.readHeaderTimeout = timeout
+	}
+}
+
+// WithReadBodyTimeout sets how long the body of a request may take to arrive
+// once its header fields have been read. Clients that are slower are answered
+// with STATUS_REQUEST_TIMEOUT. 0 disables the timeout.
+func WithReadBodyTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.readBodyTimeout = timeout
+	}
+}
+
+// WithWriteTimeout sets how long the handler may take to answer a request,
+// including sending the response, once the request has been read. A response
+// that is not sent in time is cut off and its connection closed. Routes can
+// override it with handlers.WriteTimeout. 0 disables the timeout.
+func WithWriteTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.writeTimeout = timeout
+	}
+}
+
+// WithLimits sets the limits requests must stay within.
+func WithLimits(limits network.Limits) Option {
+	return func(s *Server) {
+		s.limits = limits
+	}
+}
+
+// New returns a Server configured by options, listening and ready to Serve.
+// Unless a router is given, the server answers with the default routes.
+func New(options ...Option) (*Server, error) {
+	s := &Server{
+		address:           DEFAULT_ADDRESS,
+		idleTimeout:       DEFAULT_IDLE_TIMEOUT,
+		readHeaderTimeout: DEFAULT_READ_HEADER_TIMEOUT,
+		readBodyTimeout:   DEFAULT_READ_BODY_TIMEOUT,
+		writeTimeout:      DEFAULT_WRITE_TIMEOUT,
+		limits:            network.DefaultLimits,
+		retryAfter:        DEFAULT_RETRY_AFTER,
+		errorLog:          log.Default(),
+		conns:             map[net.Conn]connState{},
+	}
+	s.baseCtx, s.cancelBase = context.WithCancel(context.Background())
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.maxWorkers > 0 {
+		s.queue = make(chan net.Conn, s.queueSize)
+	}
+
+	if s.router == nil {
+		if err := s.useDefaultRouter(); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.listener == nil {
+		listener, err := net.Listen("tcp", s.address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", s.address, err)
+		}
+		s.listener = listener
+	}
+
+	return s, nil
+}
+
+func (s *Server) useDefaultRouter() error {
+	if s.db == nil {
+		db, err := database.Open(database.MEMORY_URL)
+		if err != nil {
+			return fmt.Errorf("could not open database: %w", err)
+		}
+		s.db, s.ownsDb = db, true
+	}
+
+	users, err := userrepository.NewUserRepository(s.db)
+	if err != nil {
+		return err
+	}
+
+	s.router, err = handlers.NewDefaultRouter(users)
+	return err
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Router returns the router the server answers requests with.
+func (s *Server) Router() *handlers.Router {
+	return s.router
+}
+
+// Serve accepts connections and serves each of them in its own goroutine, or
+// with the worker pool set up by WithMaxConnections.
+// It returns ErrServerClosed after Shutdown or Close, and otherwise the error
+// of a listener that was closed by someone else.
+func (s *Server) Serve() error {
+	s.startWorkers()
+	defer s.stopWorkers()
+
+	limiter := &rateLimitedLog{logger: s.errorLog, interval: acceptLogInterval}
+	for {
+		conn, err := s.accept(limiter)
+		if err != nil {
+			return err
+		}
+
+		s.dispatch(conn)
+	}
+}
+
+// Shutdown stops accepting connections, closes the idle ones and waits for
+// the active ones to finish their current request, then closes the database
+// if the server opened it. A database given with WithDatabase is left open
+// for its owner to close.
+// If ctx ends first, the contexts of the remaining requests are cancelled,
+// their connections are closed and the error of ctx is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shuttingDown.Store(true)
+	err := s.listener.Close()
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+
+	for !s.closeIdleConns() {
+		select {
+		case <-ctx.Done():
+			s.cancelBase()
+			s.closeConns()
+			s.closeDb()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	if dbErr := s.closeDb(); err == nil {
+		err = dbErr
+	}
+	return err
+}
+
+// Close stops the listener and closes every connection immediately, along
+// with the database if the server opened it. The contexts of active requests
+// are cancelled.
+func (s *Server) Close() error {
+	s.shuttingDown.Store(true)
+	s.cancelBase()
+	err := s.listener.Close()
+	s.closeConns()
+
+	if dbErr := s.closeDb(); err == nil {
+		err = dbErr
+	}
+	return err
+}
+
+func (s *Server) closeDb() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.ownsDb {
+		return nil
+	}
+
+	s.ownsDb = false
+	return s.db.Close()
+}
+
+// connState is what an open connection is doing.
+type connState int
+
+const (
+	// stateQueued connections wait for a worker.
+	stateQueued connState = iota
+	// stateIdle connections have a worker and wait for the next request.
+	stateIdle
+	// stateActive connections are reading or answering a request.
+	stateActive
+)
+
+// trackConn records the state of conn.
+func (s *Server) trackConn(conn net.Conn, state connState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conns[conn] = state
+}
+
+func (s *Server) forgetConn(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conns, conn)
+}
+
+// closeIdleConns closes the connections that are waiting for a request or
+// for a worker and reports whether no connections are left.
+func (s *Server) closeIdleConns() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn, state := range s.conns {
+		if state != stateActive {
+			conn.Close()
+			delete(s.conns, conn)
+		}
+	}
+
+	return len(s.conns) == 0
+}
+
+func (s *Server) closeConns() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+// handleConnection serves requests on conn until the client asks to close it,
+// hangs up, stays idle for longer than the idle timeout or the server shuts
+// down.
+// Pipelined requests are answered in the order they were sent, and requests
+// that are malformed, exceed limits or arrive too slowly are answered with an
+// error before the connection is closed.
+func (s *Server) handleConnection(conn net.Conn) {
+	defer s.forgetConn(conn)
+	defer conn.Close()
+	defer func() {
+		// Panics while answering a request are handled by serveRequest, so
+		// this one happened while reading a request and nothing has been
+		// written for it yet.
+		if err := recover(); err != nil {
+			s.errorLog.Printf("Panic reading a request from %s: %v\n%s", conn.RemoteAddr(), err, debug.Stack())
+
+			conn.SetWriteDeadline(deadline(s.writeTimeout))
+			response := network.NewResponse(conn, models.HttpRequest{})
+			network.SendText(response, network.STATUS_INTERNAL_SERVER_ERROR, "Internal Server Error")
+			response.Finish()
+		}
+	}()
+
+	cr := newConnReader(conn)
+	reader := bufio.NewReader(cr)
+	for {
+		conn.SetReadDeadline(deadline(s.idleTimeout))
+
+		// The connection is idle until the first byte of the next request
+		// arrives, and may be closed by Shutdown or to free its worker for a
+		// queued connection until then. A pipelined request that has already
+		// been read into the buffer keeps it active.
+		idle := reader.Buffered() == 0
+		if idle {
+			s.trackConn(conn, stateIdle)
+		} else {
+			s.trackConn(conn, stateActive)
+		}
+		if s.shuttingDown.Load() || (idle && s.saturated()) {
+			return
+		}
+		if _, err := reader.Peek(1); err != nil {
+			return
+		}
+		s.trackConn(conn, stateActive)
+
+		http_request, err := s.readRequest(conn, reader)
+		if err != nil {
+			var requestErr *network.RequestError
+			if errors.As(err, &requestErr) {
+				conn.SetWriteDeadline(deadline(s.writeTimeout))
+				response := network.NewResponse(conn, models.HttpRequest{})
+				network.SendText(response, requestErr.Status, requestErr.Error())
+				response.Finish()
+				network.CloseAfterError(conn)
+			}
+			return
+		}
+
+		conn.SetWriteDeadline(deadline(s.writeTimeout))
+		if !s.serveRequest(conn, cr, reader, http_request) || s.shuttingDown.Load() {
+			return
+		}
+	}
+}
+
+// readRequest reads the head of a request whose first byte has arrived and
+// prepares its body to be streamed by the handler. The head has to arrive
+// within the read header timeout, and the body within the read body timeout
+// that starts once the head has been read. A head that does not arrive in
+// time is reported as a *network.RequestError with STATUS_REQUEST_TIMEOUT.
+// Errors other than the client hanging up are logged.
+func (s *Server) readRequest(conn net.Conn, reader *bufio.Reader) (models.HttpRequest, error) {
+	conn.SetReadDeadline(deadline(s.readHeaderTimeout))
+	request, err := network.ReadRequestHead(reader, s.limits)
+	if err == nil {
+		conn.SetReadDeadline(deadline(s.readBodyTimeout))
+		err = network.ReadRequestBody(reader, &request, s.limits)
+	}
+
+	if err == nil || errors.Is(err, io.EOF) {
+		return request, err
+	}
+
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		err = &network.RequestError{Status: network.STATUS_REQUEST_TIMEOUT, Err: network.ErrRequestTimeout}
+	}
+
+	s.errorLog.Printf("Cannot read request from %s: %v", conn.RemoteAddr(), err)
+	return request, err
+}
+
+// deadline returns the deadline for something that may take timeout, or no
+// deadline if timeout is 0.
+func deadline(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(timeout)
+}
+
+// serveRequest answers request on conn and reports whether the connection can
+// be used for another request.
+// The context of the request is cancelled once it has been answered, or
+// earlier if the client hangs up while it waits. Disconnects are only noticed
+// once the body has been read, and not while the next pipelined request is
+// already buffered in reader.
+// The part of the body the handler leaves unread is discarded, unless it is
+// larger than maxDiscardBytes, in which case the connection is closed.
+// A panic while answering is logged with its stack trace. It is answered with
+// STATUS_INTERNAL_SERVER_ERROR if no part of the response has been sent yet,
+// and the connection is closed either way since the handler may have left it
+// in an unknown state.
+func (s *Server) serveRequest(conn net.Conn, cr *connReader, reader *bufio.Reader, request models.HttpRequest) (keepAlive bool) {
+	ctx, cancel := context.WithCancel(s.baseCtx)
+	defer cancel()
+
+	request = request.WithContext(ctx)
+	response := network.NewResponse(conn, request)
+
+	body := request.Body.(*network.Body)
+	body.SetConn(conn)
+	body.OnEOF(func() {
+		if reader.Buffered() == 0 {
+			cr.startBackgroundRead(cancel)
+		}
+	})
+	defer cr.abortPendingRead()
+
+	defer func() {
+		if err := recover(); err != nil {
+			s.errorLog.Printf("Panic serving %s %s for %s: %v\n%s", request.Method, request.Path, conn.RemoteAddr(), err, debug.Stack())
+
+			if response.Reset() {
+				response.Header().Set("Connection", "close")
+				network.SendText(response, network.STATUS_INTERNAL_SERVER_ERROR, "Internal Server Error")
+				response.Finish()
+			}
+			keepAlive = false
+		}
+	}()
+
+	s.router.RouteConnection(response, request)
+	body.Close()
+	discarded := body.Discard(maxDiscardBytes)
+
+	// Closing the connection during shutdown lets it finish sooner, and
+	// while connections wait for a worker it frees this one for them.
+	if (!discarded || s.shuttingDown.Load() || s.saturated()) && !response.Committed() {
+		response.Header().Set("Connection", "close")
+	}
+
+	return response.Finish() == nil && response.KeepAlive() && discarded
+}
@@ -0,0 +1,711 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"http-server/internal/handlers"
+	"http-server/internal/models"
+	"http-server/internal/network"
+	testingutil "http-server/internal/util/testing"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type isolatedServerTest struct {
+	testingutil.BasicTest
+	router   *handlers.Router
+	requests []string
+}
+
+func (test isolatedServerTest) String() string {
+	return test.Description
+}
+
+func TestServersAreIsolated(t *testing.T) {
+	const TEST_FUNCTION = "Server.Serve"
+
+	greeter := handlers.NewRouter()
+	greeter.Handle(handlers.GET, "/hello", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_OK, "greeter")
+	})
+
+	tests := []isolatedServerTest{
+		{
+			testingutil.BasicTest{
+				Description: "Custom router",
+				Want:        "200 greeter|404",
+			},
+			greeter,
+			[]string{"GET /hello", "GET /users?id=1"},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Default router with its own database",
+				Want:        "200 Created user|200 {\"id\":1,\"username\":\"daniel\",\"password\":\"123456\"}",
+			},
+			nil,
+			[]string{"POST /users/create {\"username\":\"daniel\",\"password\":\"123456\"}", "GET /users/1"},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Default router with another database",
+				Want:        "400 No such ID exists.|200 Hello World",
+			},
+			nil,
+			[]string{"GET /users?id=1", "GET /hello"},
+		},
+	}
+
+	executeTest := func(t *testing.T, tt isolatedServerTest) string {
+		options := []Option{WithAddress("127.0.0.1:0")}
+		if tt.router != nil {
+			options = append(options, WithRouter(tt.router))
+		}
+
+		s, err := New(options...)
+		if err != nil {
+			t.Fatalf("New returned '%s'", err)
+		}
+		defer s.Close()
+		go s.Serve()
+
+		responses := []string{}
+		for _, request := range tt.requests {
+			responses = append(responses, roundTrip(t, s.Addr(), request))
+		}
+
+		return strings.Join(responses, "|")
+	}
+
+	validateTest := func(t *testing.T, tt isolatedServerTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%q) = %q, want: %q", TEST_FUNCTION, tt.requests, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	handler := testingutil.GetTestHandler(executeTest, validateTest, func() {})
+	for _, tt := range tests {
+		t.Run(tt.String(), func(t *testing.T) {
+			t.Parallel()
+			handler(t, tt)
+		})
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	const TEST_FUNCTION = "Server.Serve"
+
+	router := handlers.NewRouter()
+	router.Handle(handlers.GET, "/panic", func(w network.ResponseWriter, _ models.HttpRequest) {
+		w.Header().Set("X-Partial", "true")
+		w.Write([]byte("partial"))
+		panic("handler failed")
+	})
+	router.Handle(handlers.GET, "/panic-after-flush", func(w network.ResponseWriter, _ models.HttpRequest) {
+		w.Write([]byte("hello"))
+		w.Flush()
+		panic("handler failed")
+	})
+	router.Handle(handlers.GET, "/ok", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_OK, "ok")
+	})
+
+	s, err := New(WithAddress("127.0.0.1:0"), WithRouter(router))
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+	defer s.Close()
+	go s.Serve()
+
+	tests := []isolatedServerTest{
+		{
+			testingutil.BasicTest{
+				Description: "Panic before anything was sent",
+				Want:        "500 Internal Server Error|200 ok",
+			},
+			nil,
+			[]string{"GET /panic", "GET /ok"},
+		},
+		{
+			testingutil.BasicTest{
+				Description: "Panic after the head was sent",
+				Want:        "200 5\r\nhello\r\n|200 ok",
+			},
+			nil,
+			[]string{"GET /panic-after-flush", "GET /ok"},
+		},
+	}
+
+	executeTest := func(t *testing.T, tt isolatedServerTest) string {
+		responses := []string{}
+		for _, request := range tt.requests {
+			responses = append(responses, roundTrip(t, s.Addr(), request))
+		}
+
+		return strings.Join(responses, "|")
+	}
+
+	validateTest := func(t *testing.T, tt isolatedServerTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%q) = %q, want: %q", TEST_FUNCTION, tt.requests, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestShutdown(t *testing.T) {
+	started := make(chan struct{})
+	router := handlers.NewRouter()
+	router.Handle(handlers.GET, "/slow", func(w network.ResponseWriter, _ models.HttpRequest) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		network.SendText(w, network.STATUS_OK, "done")
+	})
+
+	s, err := New(WithAddress("127.0.0.1:0"), WithRouter(router))
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.Serve()
+	}()
+
+	idle, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial(%s) returned '%s'", s.Addr(), err)
+	}
+	defer idle.Close()
+
+	active, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial(%s) returned '%s'", s.Addr(), err)
+	}
+	defer active.Close()
+
+	fmt.Fprintf(active, "GET /slow HTTP/1.1\r\nHost: test\r\n\r\n")
+	<-started
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown returned '%s', want nil", err)
+	}
+
+	if err := <-serveErr; err != ErrServerClosed {
+		t.Errorf("Serve returned '%v', want: '%s'", err, ErrServerClosed)
+	}
+
+	response, _ := io.ReadAll(active)
+	if !strings.HasPrefix(string(response), "HTTP/1.1 200 OK") || !strings.Contains(string(response), "Connection: close") ||
+		!strings.HasSuffix(string(response), "done") {
+		t.Errorf("the active request was answered with %q, want a complete 200 that closes the connection", response)
+	}
+
+	idle.SetReadDeadline(time.Now().Add(time.Second))
+	if n, err := idle.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("the idle connection read %d bytes and '%v', want it to be closed", n, err)
+	}
+
+	if _, err := net.Dial("tcp", s.Addr().String()); err == nil {
+		t.Errorf("the server accepted a connection after Shutdown")
+	}
+}
+
+func TestShutdownGracePeriod(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	cancelled := make(chan error, 1)
+
+	router := handlers.NewRouter()
+	router.Handle(handlers.GET, "/stuck", func(w network.ResponseWriter, http models.HttpRequest) {
+		close(started)
+		select {
+		case <-release:
+		case <-http.Context().Done():
+			cancelled <- http.Context().Err()
+		}
+	})
+
+	s, err := New(WithAddress("127.0.0.1:0"), WithRouter(router))
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+	go s.Serve()
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial(%s) returned '%s'", s.Addr(), err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "GET /stuck HTTP/1.1\r\nHost: test\r\n\r\n")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown returned '%v', want: '%s'", err, context.DeadlineExceeded)
+	}
+
+	select {
+	case err := <-cancelled:
+		if err != context.Canceled {
+			t.Errorf("the context of the stuck request ended with '%v', want: '%s'", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("the context of the stuck request was not cancelled")
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if n, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("the stuck connection read %d bytes and '%v', want it to be closed", n, err)
+	}
+}
+
+func TestRequestContext(t *testing.T) {
+	const TEST_FUNCTION = "HttpRequest.Context"
+
+	ended := make(chan error, 1)
+	waitForContext := func(w network.ResponseWriter, http models.HttpRequest) {
+		select {
+		case <-http.Context().Done():
+			ended <- http.Context().Err()
+		case <-time.After(time.Second):
+			ended <- nil
+		}
+		network.SendText(w, network.STATUS_OK, "waited")
+	}
+
+	router := handlers.NewRouter()
+	router.Handle(handlers.GET, "/wait", waitForContext)
+	router.Handle(handlers.GET, "/deadline", waitForContext, handlers.Timeout(20*time.Millisecond))
+
+	s, err := New(WithAddress("127.0.0.1:0"), WithRouter(router))
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+	defer s.Close()
+	go s.Serve()
+
+	tests := []isolatedServerTest{
+		{
+			testingutil.BasicTest{Description: "Client disconnects", Want: context.Canceled},
+			nil,
+			[]string{"GET /wait", "close"},
+		},
+		{
+			testingutil.BasicTest{Description: "Route deadline passes", Want: context.DeadlineExceeded},
+			nil,
+			[]string{"GET /deadline"},
+		},
+	}
+
+	executeTest := func(t *testing.T, tt isolatedServerTest) error {
+		conn, err := net.Dial("tcp", s.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial(%s) returned '%s'", s.Addr(), err)
+		}
+		defer conn.Close()
+
+		fmt.Fprintf(conn, "%s HTTP/1.1\r\nHost: test\r\n\r\n", tt.requests[0])
+		if len(tt.requests) > 1 {
+			conn.Close()
+		}
+
+		return <-ended
+	}
+
+	validateTest := func(t *testing.T, tt isolatedServerTest, gotBeforeAssertion any) {
+		got, _ := gotBeforeAssertion.(error)
+		err := fmt.Sprintf("%s of %q ended with '%v', want: '%v'", TEST_FUNCTION, tt.requests, got, tt.Want)
+		testingutil.ValidateResult(t, err, got, tt.Want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}
+
+func TestPipelinedRequestDuringHandler(t *testing.T) {
+	started := make(chan struct{}, 2)
+	router := handlers.NewRouter()
+	router.Handle(handlers.GET, "/slow", func(w network.ResponseWriter, http models.HttpRequest) {
+		started <- struct{}{}
+		time.Sleep(50 * time.Millisecond)
+		if err := http.Context().Err(); err != nil {
+			network.SendText(w, network.STATUS_OK, err.Error())
+			return
+		}
+		network.SendText(w, network.STATUS_OK, "slow")
+	})
+	router.Handle(handlers.GET, "/fast", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_OK, "fast")
+	})
+
+	s, err := New(WithAddress("127.0.0.1:0"), WithRouter(router))
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+	defer s.Close()
+	go s.Serve()
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial(%s) returned '%s'", s.Addr(), err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "GET /slow HTTP/1.1\r\nHost: test\r\n\r\n")
+	<-started
+
+	// The next request arrives while the first is answered, so it is read by
+	// the background read that watches for disconnects.
+	fmt.Fprintf(conn, "GET /fast HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n")
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	response, _ := io.ReadAll(conn)
+
+	bodies := []string{}
+	for _, part := range strings.Split(string(response), "\r\n\r\n")[1:] {
+		bodies = append(bodies, strings.SplitN(part, "HTTP/1.1", 2)[0])
+	}
+	if got := strings.Join(bodies, "|"); got != "slow|fast" {
+		t.Errorf("pipelined requests were answered with %q, want: %q", got, "slow|fast")
+	}
+}
+
+func TestWorkerPool(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	router := handlers.NewRouter()
+	router.Handle(handlers.GET, "/block", func(w network.ResponseWriter, _ models.HttpRequest) {
+		started <- struct{}{}
+		<-release
+		network.SendText(w, network.STATUS_OK, "done")
+	})
+
+	s, err := New(WithAddress("127.0.0.1:0"), WithRouter(router), WithMaxConnections(1), WithQueueSize(1))
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+	defer s.Close()
+	go s.Serve()
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", s.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial(%s) returned '%s'", s.Addr(), err)
+		}
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		fmt.Fprintf(conn, "GET /block HTTP/1.1\r\nHost: test\r\n\r\n")
+		return conn
+	}
+
+	waitForStats := func(want Stats) {
+		deadline := time.Now().Add(time.Second)
+		for s.Stats() != want && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := s.Stats(); got != want {
+			t.Fatalf("Stats() = %s, want: %s", got, want)
+		}
+	}
+
+	served := dial()
+	defer served.Close()
+	<-started
+
+	queued := dial()
+	defer queued.Close()
+	waitForStats(Stats{Open: 2, Active: 1, Workers: 1, MaxWorkers: 1, Queued: 1, QueueSize: 1})
+
+	rejected := dial()
+	defer rejected.Close()
+
+	response, _ := io.ReadAll(rejected)
+	if !strings.HasPrefix(string(response), "HTTP/1.1 503 Service Unavailable") || !strings.Contains(string(response), "Retry-After: 1\r\n") {
+		t.Errorf("the connection over the limit was answered with %q, want a 503 with Retry-After", response)
+	}
+	waitForStats(Stats{Open: 2, Active: 1, Workers: 1, MaxWorkers: 1, Queued: 1, QueueSize: 1, Rejected: 1})
+
+	release <- struct{}{}
+	response, _ = io.ReadAll(served)
+	if !strings.HasPrefix(string(response), "HTTP/1.1 200 OK") || !strings.Contains(string(response), "Connection: close") {
+		t.Errorf("the served connection was answered with %q, want a 200 that frees the worker", response)
+	}
+
+	<-started
+	release <- struct{}{}
+	reader := bufio.NewReader(queued)
+	if statusLine, _ := reader.ReadString('\n'); statusLine != "HTTP/1.1 200 OK\r\n" {
+		t.Errorf("the queued connection was answered with %q, want a 200", statusLine)
+	}
+}
+
+func TestWorkerPoolFreesIdleWorkers(t *testing.T) {
+	router := handlers.NewRouter()
+	router.Handle(handlers.GET, "/hello", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_OK, "hello")
+	})
+
+	s, err := New(WithAddress("127.0.0.1:0"), WithRouter(router), WithMaxConnections(1), WithQueueSize(4), WithIdleTimeout(3*time.Second))
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+	defer s.Close()
+	go s.Serve()
+
+	idle, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial(%s) returned '%s'", s.Addr(), err)
+	}
+	defer idle.Close()
+
+	// The first connection keeps its worker after its request, waiting for
+	// the next one.
+	idle.SetDeadline(time.Now().Add(time.Second))
+	fmt.Fprintf(idle, "GET /hello HTTP/1.1\r\nHost: test\r\n\r\n")
+	if statusLine, _ := bufio.NewReader(idle).ReadString('\n'); statusLine != "HTTP/1.1 200 OK\r\n" {
+		t.Fatalf("the first connection was answered with %q, want a 200", statusLine)
+	}
+
+	start := time.Now()
+	if got := roundTrip(t, s.Addr(), "GET /hello"); got != "200 hello" {
+		t.Errorf("the queued connection was answered with %q, want: %q", got, "200 hello")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("the queued connection was answered after %s, want it served before the idle timeout", elapsed)
+	}
+
+	idle.SetReadDeadline(time.Now().Add(time.Second))
+	if n, err := idle.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("the idle connection read %d bytes and '%v', want it to be closed", n, err)
+	}
+}
+
+func TestWorkerPoolClosesOneIdleWorkerPerQueuedConnection(t *testing.T) {
+	router := handlers.NewRouter()
+	router.Handle(handlers.GET, "/hello", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_OK, "hello")
+	})
+
+	s, err := New(WithAddress("127.0.0.1:0"), WithRouter(router), WithMaxConnections(2), WithQueueSize(4), WithIdleTimeout(3*time.Second))
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+	defer s.Close()
+	go s.Serve()
+
+	// Both workers are held by connections that wait for their next request.
+	idle := make([]net.Conn, 2)
+	readers := make([]*bufio.Reader, 2)
+	for i := range idle {
+		conn, err := net.Dial("tcp", s.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial(%s) returned '%s'", s.Addr(), err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+		idle[i], readers[i] = conn, bufio.NewReader(conn)
+		if response := sendRequest(idle[i], readers[i]); response != "200 hello" {
+			t.Fatalf("idle connection %d was answered with %q, want: %q", i, response, "200 hello")
+		}
+	}
+
+	if got := roundTrip(t, s.Addr(), "GET /hello"); got != "200 hello" {
+		t.Errorf("the queued connection was answered with %q, want: %q", got, "200 hello")
+	}
+
+	open := 0
+	for i := range idle {
+		if sendRequest(idle[i], readers[i]) == "200 hello" {
+			open++
+		}
+	}
+	if open != 1 {
+		t.Errorf("%d idle connections were left open for one queued connection, want: 1", open)
+	}
+}
+
+// sendRequest sends a GET /hello request on conn and returns the status code
+// and body of the response read from reader, or "" if the connection fails.
+func sendRequest(conn net.Conn, reader *bufio.Reader) string {
+	if _, err := fmt.Fprintf(conn, "GET /hello HTTP/1.1\r\nHost: test\r\n\r\n"); err != nil {
+		return ""
+	}
+
+	statusLine, err := reader.ReadString('\n')
+	if err != nil {
+		return ""
+	}
+
+	length := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return ""
+		}
+		if line == "\r\n" {
+			break
+		}
+		fmt.Sscanf(line, "Content-Length: %d", &length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return ""
+	}
+	return strings.Fields(statusLine)[1] + " " + string(body)
+}
+
+// roundTrip sends request, written as "METHOD PATH [BODY]", on a new
+// connection to addr and returns the status code and body of the response.
+// The body is left out of responses with a 404 status.
+func roundTrip(t *testing.T, addr net.Addr, request string) string {
+	method, rest, _ := strings.Cut(request, " ")
+	path, body, _ := strings.Cut(rest, " ")
+
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("net.Dial(%s) returned '%s'", addr, err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "%s %s HTTP/1.1\r\nHost: test\r\nConnection: close\r\nContent-Length: %d\r\n\r\n%s",
+		method, path, len(body), body)
+
+	reader := bufio.NewReader(conn)
+	statusLine, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading the response to %q returned '%s'", request, err)
+	}
+	status := strings.Fields(statusLine)[1]
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading the response to %q returned '%s'", request, err)
+		}
+		if line == "\r\n" {
+			break
+		}
+	}
+
+	if status == "404" {
+		return status
+	}
+
+	data, _ := io.ReadAll(reader)
+	return status + " " + string(data)
+}
+
+type timeoutTest struct {
+	testingutil.BasicTest
+	request string
+}
+
+func (test timeoutTest) String() string {
+	return test.Description
+}
+
+func TestTimeouts(t *testing.T) {
+	const TEST_FUNCTION = "Server.Serve"
+
+	router := handlers.NewRouter()
+	router.Handle(handlers.GET, "/hello", func(w network.ResponseWriter, _ models.HttpRequest) {
+		network.SendText(w, network.STATUS_OK, "hello")
+	})
+	router.Handle(handlers.GET, "/slow", func(w network.ResponseWriter, _ models.HttpRequest) {
+		time.Sleep(50 * time.Millisecond)
+		network.SendText(w, network.STATUS_OK, "too late")
+	}, handlers.WriteTimeout(10*time.Millisecond))
+	echo := func(w network.ResponseWriter, http models.HttpRequest) {
+		body, err := http.ReadBodyString()
+		if requestErr := (*network.RequestError)(nil); errors.As(err, &requestErr) {
+			network.SendText(w, requestErr.Status, requestErr.Error())
+			return
+		}
+		network.SendText(w, network.STATUS_OK, body)
+	}
+	router.Handle(handlers.POST, "/echo", echo)
+	router.Handle(handlers.POST, "/impatient", echo, handlers.ReadTimeout(5*time.Millisecond))
+
+	s, err := New(
+		WithAddress("127.0.0.1:0"),
+		WithRouter(router),
+		WithIdleTimeout(30*time.Millisecond),
+		WithReadHeaderTimeout(30*time.Millisecond),
+		WithReadBodyTimeout(30*time.Millisecond),
+		WithWriteTimeout(time.Second),
+		WithErrorLog(log.New(io.Discard, "", 0)),
+	)
+	if err != nil {
+		t.Fatalf("New returned '%s'", err)
+	}
+	defer s.Close()
+	go s.Serve()
+
+	tests := []timeoutTest{
+		{
+			testingutil.BasicTest{Description: "Request in time", Want: "HTTP/1.1 200 OK"},
+			"GET /hello HTTP/1.1\r\nHost: test\r\nConnection: close\r\n\r\n",
+		},
+		{
+			testingutil.BasicTest{Description: "Idle connection is closed silently", Want: ""},
+			"",
+		},
+		{
+			testingutil.BasicTest{Description: "Slow header fields", Want: "HTTP/1.1 408 Request Timeout"},
+			"GET /hello HTTP/1.1\r\nHost: te",
+		},
+		{
+			testingutil.BasicTest{Description: "Slow body", Want: "HTTP/1.1 408 Request Timeout"},
+			"POST /echo HTTP/1.1\r\nHost: test\r\nContent-Length: 10\r\n\r\nabc",
+		},
+		{
+			testingutil.BasicTest{Description: "Slow body the handler does not read", Want: "HTTP/1.1 200 OK"},
+			"GET /hello HTTP/1.1\r\nHost: test\r\nContent-Length: 10\r\n\r\nabc",
+		},
+		{
+			testingutil.BasicTest{Description: "Route read timeout passes", Want: "HTTP/1.1 408 Request Timeout"},
+			"POST /impatient HTTP/1.1\r\nHost: test\r\nTransfer-Encoding: chunked\r\n\r\n3\r\nabc\r\n",
+		},
+		{
+			testingutil.BasicTest{Description: "Route write timeout passes", Want: ""},
+			"GET /slow HTTP/1.1\r\nHost: test\r\n\r\n",
+		},
+	}
+
+	executeTest := func(t *testing.T, tt timeoutTest) string {
+		conn, err := net.Dial("tcp", s.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial(%s) returned '%s'", s.Addr(), err)
+		}
+		defer conn.Close()
+
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		fmt.Fprint(conn, tt.request)
+
+		response, _ := io.ReadAll(conn)
+		statusLine, _, _ := strings.Cut(string(response), "\r\n")
+		return statusLine
+	}
+
+	validateTest := func(t *testing.T, tt timeoutTest, gotBeforeAssertion any) {
+		got, want := testingutil.AssertGotAndWantType[string](t, gotBeforeAssertion, tt.Want)
+		err := fmt.Sprintf("%s(%q) was answered with %q, want: %q", TEST_FUNCTION, tt.request, got, want)
+		testingutil.ValidateResult(t, err, got, want)
+	}
+
+	testingutil.HandleTests(t, tests, testingutil.GetTestHandler(executeTest, validateTest, func() {}))
+}